@@ -0,0 +1,78 @@
+package password
+
+import (
+	"context"
+	"io"
+)
+
+// VerifyAbsent takes passphrase and masterpassphrase as strings, userparams as ScryptParams or Argon2Params and
+// masterparams as ScryptParams, and performs the same work as Verify of a hash created with those parameters: a
+// master passphrase Scrypt hash with a random salt, decryption of a synthetic sealed box and the user passphrase KDF.
+// Call it when no hash is stored for the given user so that response time and memory use do not reveal whether the
+// user exists. It always returns ErrPassphraseHashMismatch unless the parameters are invalid or the random source fails.
+func VerifyAbsent(userpass, masterpass string, userparams UserParams, masterparams ScryptParams) error {
+	if err := validateUser(userparams); err != nil {
+		return err
+	}
+	if err := validateMaster(masterparams); err != nil {
+		return err
+	}
+	key, _, err := newMasterKey(context.Background(), nil, masterpass, masterparams)
+	if err != nil {
+		return err
+	}
+	return verifyAbsent(nil, userpass, &key, userparams)
+}
+
+// VerifyAbsent takes passphrase as string and userparams as ScryptParams or Argon2Params and performs the same work
+// as Verify of a hash created by this Hasher, always returning ErrPassphraseHashMismatch unless userparams are invalid.
+// See the package level VerifyAbsent.
+func (h *Hasher) VerifyAbsent(userpass string, userparams UserParams) error {
+	if err := validateUser(userparams); err != nil {
+		return err
+	}
+	return verifyAbsent(h.rand, userpass, &h.key, userparams)
+}
+
+// VerifyAbsent performs the same work as Verify of a hash created by the current master passphrase version's Hasher,
+// always returning ErrPassphraseHashMismatch unless userparams are invalid. See the package level VerifyAbsent.
+func (k *Keyring) VerifyAbsent(userpass string, userparams UserParams) error {
+	h, err := k.Hasher(k.Current())
+	if err != nil {
+		return err
+	}
+	return h.VerifyAbsent(userpass, userparams)
+}
+
+// syntheticOutput is implemented by UserParams whose verify rejects random bytes before running the KDF
+type syntheticOutput interface {
+	// synthetic returns a well formed output of hash that matches no passphrase
+	synthetic(random io.Reader) ([]byte, error)
+}
+
+// verifyAbsent opens a sealed box of random content under key and compares it against the user passphrase KDF output
+func verifyAbsent(random io.Reader, userpass string, key *[32]byte, userparams UserParams) error {
+	var synthetic []byte
+	var err error
+	if s, ok := userparams.(syntheticOutput); ok {
+		synthetic, err = s.synthetic(random)
+	} else {
+		synthetic, err = randBytes(random, userparams.size())
+	}
+	if err != nil {
+		return err
+	}
+	sealed, err := seal(random, key, synthetic)
+	if err != nil {
+		return err
+	}
+	decrypted, err := open(key, sealed)
+	if err != nil {
+		return err
+	}
+	// The random KDF salt and output never match, the comparison still runs in constant time
+	if err = userparams.verify(context.Background(), userpass, decrypted); err != nil && err != ErrPassphraseHashMismatch {
+		return err
+	}
+	return ErrPassphraseHashMismatch
+}
@@ -0,0 +1,51 @@
+package password
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerifyAbsent(t *testing.T) {
+	userparams := ScryptParams{N: 32768, R: 16, P: 1}
+	output, err := Hash("password1234", "masterpassphrase", 0, userparams, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	start := time.Now()
+	if err = Verify("passw0rd1234", "masterpassphrase", output); err != ErrPassphraseHashMismatch {
+		t.Log("Expected passphrase mismatch")
+		t.FailNow()
+	}
+	present := time.Since(start)
+	start = time.Now()
+	if err = VerifyAbsent("passw0rd1234", "masterpassphrase", userparams, DefaultParams); err != ErrPassphraseHashMismatch {
+		t.Logf("Expected passphrase mismatch, got %v", err)
+		t.FailNow()
+	}
+	t.Logf("Verify %v, VerifyAbsent %v", present, time.Since(start))
+
+	if err = VerifyAbsent("password1234", "masterpassphrase", testArgon2Params, DefaultParams); err != ErrPassphraseHashMismatch {
+		t.Logf("Expected passphrase mismatch, got %v", err)
+		t.FailNow()
+	}
+	if err = VerifyAbsent("password1234", "masterpassphrase", nil, DefaultParams); !errors.Is(err, ErrUnsupportedKDF) {
+		t.Log("Expected unsupported KDF error")
+		t.FailNow()
+	}
+	if err = VerifyAbsent("password1234", "masterpassphrase", userparams, ScryptParams{}); !errors.Is(err, ErrScryptParamN) {
+		t.Log("Expected invalid params error")
+		t.FailNow()
+	}
+
+	k, err := NewKeyring(0, DefaultParams, map[int]string{0: "masterpassphrase"})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err = k.VerifyAbsent("password1234", testArgon2Params); err != ErrPassphraseHashMismatch {
+		t.Logf("Expected passphrase mismatch, got %v", err)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,137 @@
+package password
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// KDFBcrypt identifies bcrypt hashes imported with ImportBcrypt in secBoxv2 hashes
+const KDFBcrypt = "bcrypt"
+
+// bcryptHashLen is the length of a bcrypt hash string, ex. $2a$10$ followed by 53 characters
+const bcryptHashLen = 60
+
+// BcryptParams sets the bcrypt cost of a hash. It is used to wrap existing bcrypt hashes with ImportBcrypt so that
+// they gain the master passphrase layer before users next log in, at which point VerifyAndUpgrade can replace them
+// with Argon2id or Scrypt. Unlike the other KDFs the user passphrase is not prehashed with Blake2b, as existing bcrypt
+// hashes were computed from the raw passphrase, so only its first 72 bytes are significant.
+type BcryptParams struct {
+	Cost int
+}
+
+// KDF returns "bcrypt"
+func (p BcryptParams) KDF() string { return KDFBcrypt }
+
+func (p BcryptParams) encode() string {
+	return fmt.Sprintf("c=%v", p.Cost)
+}
+
+func (p BcryptParams) validate() error {
+	if p.Cost < bcrypt.MinCost || p.Cost > bcrypt.MaxCost {
+		return &ParamError{Param: "Cost", Value: int64(p.Cost), Min: int64(bcrypt.MinCost), Max: int64(bcrypt.MaxCost), Err: ErrBcryptParamCost}
+	}
+	return nil
+}
+
+func (p BcryptParams) size() int { return bcryptHashLen }
+
+// hash computes a new bcrypt hash, its salt is always read from crypto/rand
+func (p BcryptParams) hash(ctx context.Context, random io.Reader, userpass string) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(userpass), p.Cost)
+}
+
+func (p BcryptParams) verify(ctx context.Context, userpass string, decrypted []byte) error {
+	if cost, err := bcrypt.Cost(decrypted); err != nil || cost != p.Cost {
+		return ErrCiphertextFormat
+	}
+	if bcrypt.CompareHashAndPassword(decrypted, []byte(userpass)) != nil {
+		return ErrPassphraseHashMismatch
+	}
+	return nil
+}
+
+// bcryptAlphabet is the base64 alphabet of bcrypt hashes
+const bcryptAlphabet = "./ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// synthetic returns a bcrypt hash of p.Cost with random salt and hash for VerifyAbsent
+func (p BcryptParams) synthetic(random io.Reader) ([]byte, error) {
+	prefix := fmt.Sprintf("$2a$%02d$", p.Cost)
+	b, err := randBytes(random, bcryptHashLen-len(prefix))
+	if err != nil {
+		return nil, err
+	}
+	for i := range b {
+		b[i] = bcryptAlphabet[b[i]&63]
+	}
+	return append([]byte(prefix), b...), nil
+}
+
+// ImportBcrypt takes an existing bcrypt hash, ex. $2a$10$..., masterpassphrase as string, version indicator as int and
+// masterparams as ScryptParams and returns the bcrypt hash encrypted by the master layer as a secBoxv2 ciphertext
+// string with KDF "bcrypt", and error. Verify checks passphrases against it with bcrypt after decryption.
+func ImportBcrypt(bcryptHash, masterpass string, version int, masterparams ScryptParams) (pwHashOut string, err error) {
+	if len(masterpass) < MinLength {
+		return "", ErrPassphraseLength
+	}
+	err = validateMaster(masterparams)
+	if err != nil {
+		return "", err
+	}
+	out, err := newBcryptHash(bcryptHash)
+	if err != nil {
+		return "", err
+	}
+	out.MasterVersion = version
+	out.MasterParams = masterparams
+	key, salt, err := newMasterKey(context.Background(), nil, masterpass, masterparams)
+	if err != nil {
+		return "", err
+	}
+	out.MasterSalt = salt
+	if err = out.seal(nil, &key, []byte(bcryptHash)); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// ImportBcrypt takes an existing bcrypt hash and returns it encrypted by the Hasher's master layer, see ImportBcrypt
+func (h *Hasher) ImportBcrypt(bcryptHash string) (pwHashOut string, err error) {
+	out, err := newBcryptHash(bcryptHash)
+	if err != nil {
+		return "", err
+	}
+	out.MasterVersion = h.version
+	out.Cipher = h.Cipher()
+	out.MasterSalt = h.salt
+	out.MasterParams = h.masterParams
+	out.Version = formatVersion(out.Cipher)
+	if err = out.seal(h.rand, &h.key, []byte(bcryptHash)); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// ImportBcrypt takes an existing bcrypt hash and returns it encrypted under the current master passphrase version
+func (k *Keyring) ImportBcrypt(bcryptHash string) (string, error) {
+	h, err := k.Hasher(k.Current())
+	if err != nil {
+		return "", err
+	}
+	return h.ImportBcrypt(bcryptHash)
+}
+
+// newBcryptHash validates bcryptHash and returns a secBoxv2 ParsedHash with its cost as user parameters
+func newBcryptHash(bcryptHash string) (*ParsedHash, error) {
+	cost, err := bcrypt.Cost([]byte(bcryptHash))
+	if err != nil || len(bcryptHash) != bcryptHashLen {
+		return nil, ErrBcryptHash
+	}
+	params := BcryptParams{Cost: cost}
+	if err = validateUser(params); err != nil {
+		return nil, err
+	}
+	return &ParsedHash{Version: 2, Cipher: CipherSecretbox, UserParams: params}, nil
+}
@@ -0,0 +1,85 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestImportBcrypt(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password1234"), bcrypt.MinCost)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	output, err := ImportBcrypt(string(legacy), "masterpassphrase", 0, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if !strings.HasPrefix(output, "secBoxv2$0$bcrypt$c=4$") {
+		t.Logf("Unexpected output: %s", output)
+		t.FailNow()
+	}
+	if strings.Contains(output, string(legacy[7:])) {
+		t.Log("Expected bcrypt hash to be encrypted")
+		t.FailNow()
+	}
+	if err = Verify("password1234", "masterpassphrase", output); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err = Verify("passw0rd1234", "masterpassphrase", output); err != ErrPassphraseHashMismatch {
+		t.Log("Expected passphrase mismatch")
+		t.FailNow()
+	}
+	if err = Verify("password1234", "masterpassphras3", output); err != ErrSecretBoxDecryptFail {
+		t.Log("Expected master passphrase mismatch")
+		t.FailNow()
+	}
+	phc, err := EncodePHC(output)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if decoded, err := DecodePHC(phc); err != nil || decoded != output {
+		t.Log("Expected PHC round trip", err)
+		t.FailNow()
+	}
+
+	// Imported hashes are replaced with a native KDF on login
+	k, err := NewKeyring(0, DefaultParams, map[int]string{0: "masterpassphrase"})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	imported, err := k.ImportBcrypt(string(legacy))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	upgraded, err := VerifyAndUpgrade("password1234", k, imported, RehashPolicy{UserParams: testArgon2Params})
+	if err != nil || upgraded == "" {
+		t.Log("Expected upgrade to Argon2id", err)
+		t.FailNow()
+	}
+	if kdf, _, _ := GetKDFParams(upgraded); kdf != testArgon2Params {
+		t.Log("Expected Argon2id parameters")
+		t.FailNow()
+	}
+
+	if err = k.VerifyAbsent("password1234", BcryptParams{Cost: bcrypt.MinCost}); err != ErrPassphraseHashMismatch {
+		t.Log("Expected VerifyAbsent mismatch, got", err)
+		t.FailNow()
+	}
+	if _, err = ImportBcrypt("$2a$04$tooshort", "masterpassphrase", 0, DefaultParams); err != ErrBcryptHash {
+		t.Log("Expected ErrBcryptHash")
+		t.FailNow()
+	}
+	if _, err = HashV2("password1234", "masterpassphrase", 0, BcryptParams{Cost: 3}, DefaultParams); !errors.Is(err, ErrBcryptParamCost) {
+		t.Log("Expected ErrBcryptParamCost")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,225 @@
+package password
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BreachChecker looks up passphrases in a corpus of breached passwords such as Have I Been Pwned's Pwned Passwords.
+// Implementations must be safe for concurrent use.
+type BreachChecker interface {
+	// Count returns the number of times userpass appears in the corpus, 0 if it does not
+	Count(ctx context.Context, userpass string) (int64, error)
+}
+
+// hibpDefaultRangeURL is the Pwned Passwords range API, the first 5 hex characters of the SHA-1 hash are appended
+const hibpDefaultRangeURL = "https://api.pwnedpasswords.com/range/"
+
+// sha1Hex returns the upper case hex SHA-1 hash of userpass as used by Pwned Passwords
+func sha1Hex(userpass string) string {
+	sum := sha1.Sum([]byte(userpass))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// HIBPFile looks up passphrases by binary search in a Pwned Passwords SHA-1 file ordered by hash, one HASH:COUNT
+// line per password. The file is read with ReadAt so it is never loaded into memory.
+type HIBPFile struct {
+	f    *os.File
+	size int64
+}
+
+// OpenHIBPFile opens the Pwned Passwords SHA-1 file ordered by hash at path, ex. pwned-passwords-sha1-ordered-by-hash-v8.txt
+func OpenHIBPFile(path string) (*HIBPFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &HIBPFile{f: f, size: info.Size()}, nil
+}
+
+// Close closes the file
+func (h *HIBPFile) Close() error {
+	return h.f.Close()
+}
+
+// Count returns the prevalence count of userpass in the file, 0 if it is not present
+func (h *HIBPFile) Count(ctx context.Context, userpass string) (int64, error) {
+	target := []byte(sha1Hex(userpass))
+	// Lines starting before lo hash below target, the first line starting at or after hi hashes at or above it
+	lo, hi := int64(0), h.size
+	for lo < hi {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		mid := lo + (hi-lo)/2
+		start, line, err := h.lineFrom(mid)
+		if err != nil {
+			return 0, err
+		}
+		if line == nil || start >= hi || bytes.Compare(hashField(line), target) >= 0 {
+			hi = mid
+			continue
+		}
+		lo = start + int64(len(line)) + 1
+		if lo > hi {
+			lo = hi
+		}
+	}
+	_, line, err := h.lineFrom(lo)
+	if err != nil || line == nil {
+		return 0, err
+	}
+	if !bytes.Equal(hashField(line), target) {
+		return 0, nil
+	}
+	return parseCount(line[len(target):])
+}
+
+// lineFrom returns the first line starting at or after off without its line ending, or nil at the end of the file
+func (h *HIBPFile) lineFrom(off int64) (start int64, line []byte, err error) {
+	buf := make([]byte, 256)
+	readFrom := off
+	if off > 0 {
+		// Read from the preceding byte to tell whether off starts a line
+		readFrom = off - 1
+	}
+	n, err := h.f.ReadAt(buf, readFrom)
+	if err != nil && err != io.EOF {
+		return 0, nil, err
+	}
+	buf = buf[:n]
+	start = readFrom
+	if off > 0 {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			if n < cap(buf) {
+				return 0, nil, nil
+			}
+			return 0, nil, fmt.Errorf("%w: line too long at offset %v", ErrBreachCorpus, off)
+		}
+		buf = buf[i+1:]
+		start += int64(i) + 1
+	}
+	if len(buf) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+		buf = buf[:i]
+	} else if n == cap(buf) {
+		return 0, nil, fmt.Errorf("%w: line too long at offset %v", ErrBreachCorpus, start)
+	}
+	return start, buf, nil
+}
+
+// hashField returns the upper cased hash preceding the colon of a HASH:COUNT line
+func hashField(line []byte) []byte {
+	if i := bytes.IndexByte(line, ':'); i >= 0 {
+		line = line[:i]
+	}
+	return bytes.ToUpper(line)
+}
+
+// parseCount parses the :COUNT suffix of a line
+func parseCount(s []byte) (int64, error) {
+	s = bytes.TrimRight(s, "\r")
+	if len(s) < 2 || s[0] != ':' {
+		return 0, fmt.Errorf("%w: missing count", ErrBreachCorpus)
+	}
+	count, err := strconv.ParseInt(string(s[1:]), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrBreachCorpus, err)
+	}
+	return count, nil
+}
+
+// HIBPRangeDir looks up passphrases in a directory of Pwned Passwords range files, as written by the Pwned Passwords
+// downloader. Each file is named by the first 5 hex characters of the SHA-1 hash, ex. 21BD1.txt, and holds SUFFIX:COUNT
+// lines for the remaining 35 characters.
+type HIBPRangeDir struct {
+	dir string
+}
+
+// NewHIBPRangeDir takes the directory holding the range files and returns an HIBPRangeDir
+func NewHIBPRangeDir(dir string) *HIBPRangeDir {
+	return &HIBPRangeDir{dir: dir}
+}
+
+// Count returns the prevalence count of userpass in its range file, 0 if it is not present
+func (d *HIBPRangeDir) Count(ctx context.Context, userpass string) (int64, error) {
+	hash := sha1Hex(userpass)
+	f, err := os.Open(filepath.Join(d.dir, hash[:5]+".txt"))
+	if os.IsNotExist(err) {
+		f, err = os.Open(filepath.Join(d.dir, hash[:5]))
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return rangeCount(f, hash[5:])
+}
+
+// HIBPRangeClient looks up passphrases with the Pwned Passwords range API, or a mirror of it. Only the first 5 hex
+// characters of the SHA-1 hash are sent and responses are requested with padding, so neither the passphrase nor
+// whether it was found is disclosed.
+type HIBPRangeClient struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewHIBPRangeClient takes an HTTP client, nil for http.DefaultClient, and the range API base URL to which the hash
+// prefix is appended, "" for https://api.pwnedpasswords.com/range/, and returns an HIBPRangeClient
+func NewHIBPRangeClient(client *http.Client, baseURL string) *HIBPRangeClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if baseURL == "" {
+		baseURL = hibpDefaultRangeURL
+	}
+	return &HIBPRangeClient{client: client, baseURL: baseURL}
+}
+
+// Count returns the prevalence count of userpass reported by the range API, 0 if it is not present
+func (c *HIBPRangeClient) Count(ctx context.Context, userpass string) (int64, error) {
+	hash := sha1Hex(userpass)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+hash[:5], nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Add-Padding", "true")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("%w: range API returned %s", ErrBreachCorpus, resp.Status)
+	}
+	return rangeCount(resp.Body, hash[5:])
+}
+
+// rangeCount scans SUFFIX:COUNT lines from r for suffix, padding lines have a count of 0
+func rangeCount(r io.Reader, suffix string) (int64, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if bytes.Equal(hashField(line), []byte(suffix)) {
+			return parseCount(line[len(suffix):])
+		}
+	}
+	return 0, scanner.Err()
+}
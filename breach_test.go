@@ -0,0 +1,145 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var testBreached = map[string]int64{"password": 9659365, "123456": 37359195, "letmein1": 104, "correcthorse": 1}
+
+// writeHIBPFile writes testBreached and filler hashes as a Pwned Passwords file ordered by hash
+func writeHIBPFile(t *testing.T) string {
+	var lines []string
+	for pw, count := range testBreached {
+		lines = append(lines, fmt.Sprintf("%s:%v", sha1Hex(pw), count))
+	}
+	for i := 0; i < 500; i++ {
+		lines = append(lines, fmt.Sprintf("%s:%v", sha1Hex(fmt.Sprintf("filler%v", i)), i+1))
+	}
+	sort.Strings(lines)
+	path := filepath.Join(t.TempDir(), "pwned-passwords-sha1-ordered-by-hash.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\r\n")), 0644); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	return path
+}
+
+func testBreachChecker(t *testing.T, c BreachChecker) {
+	for pw, want := range testBreached {
+		count, err := c.Count(context.Background(), pw)
+		if err != nil || count != want {
+			t.Logf("Expected count %v for %q, got %v %v", want, pw, count, err)
+			t.FailNow()
+		}
+	}
+	for _, pw := range []string{"Tr0ub4dor&3-unbreached", "", "filler"} {
+		count, err := c.Count(context.Background(), pw)
+		if err != nil || count != 0 {
+			t.Logf("Expected %q not to be breached, got %v %v", pw, count, err)
+			t.FailNow()
+		}
+	}
+}
+
+func TestHIBPFile(t *testing.T) {
+	f, err := OpenHIBPFile(writeHIBPFile(t))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	defer f.Close()
+	testBreachChecker(t, f)
+	// Every filler line is found, including the first and last
+	for i := 0; i < 500; i++ {
+		count, err := f.Count(context.Background(), fmt.Sprintf("filler%v", i))
+		if err != nil || count != int64(i+1) {
+			t.Logf("Expected count %v for filler%v, got %v %v", i+1, i, count, err)
+			t.FailNow()
+		}
+	}
+}
+
+func TestHIBPRangeDir(t *testing.T) {
+	dir := t.TempDir()
+	ranges := make(map[string][]string)
+	for pw, count := range testBreached {
+		h := sha1Hex(pw)
+		ranges[h[:5]] = append(ranges[h[:5]], fmt.Sprintf("%s:%v", h[5:], count))
+	}
+	for prefix, lines := range ranges {
+		if err := os.WriteFile(filepath.Join(dir, prefix+".txt"), []byte(strings.Join(lines, "\r\n")), 0644); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+	}
+	d := NewHIBPRangeDir(dir)
+	for pw, want := range testBreached {
+		if count, err := d.Count(context.Background(), pw); err != nil || count != want {
+			t.Logf("Expected count %v for %q, got %v %v", want, pw, count, err)
+			t.FailNow()
+		}
+	}
+	// A missing range file means the corpus is incomplete
+	if _, err := d.Count(context.Background(), "Tr0ub4dor&3-unbreached"); !os.IsNotExist(err) {
+		t.Log("Expected missing range file error")
+		t.FailNow()
+	}
+}
+
+func TestHIBPRangeClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix := strings.TrimPrefix(r.URL.Path, "/range/")
+		if len(prefix) != 5 || r.Header.Get("Add-Padding") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for pw, count := range testBreached {
+			if h := sha1Hex(pw); h[:5] == prefix {
+				fmt.Fprintf(w, "%s:%v\r\n", h[5:], count)
+			}
+		}
+		// Padding entry
+		fmt.Fprintf(w, "%s:0\r\n", strings.Repeat("0", 35))
+	}))
+	defer server.Close()
+
+	testBreachChecker(t, NewHIBPRangeClient(server.Client(), server.URL+"/range/"))
+	if _, err := NewHIBPRangeClient(server.Client(), server.URL+"/bad/").Count(context.Background(), "password"); !errors.Is(err, ErrBreachCorpus) {
+		t.Log("Expected ErrBreachCorpus for error status")
+		t.FailNow()
+	}
+}
+
+func TestPolicyBreaches(t *testing.T) {
+	f, err := OpenHIBPFile(writeHIBPFile(t))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	defer f.Close()
+	h, err := NewHasher("masterpassphrase", 0, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	h.SetPolicy(&Policy{MinLength: 8, Breaches: f})
+	_, err = h.Hash("letmein1", testArgon2Params)
+	var perr *PolicyError
+	if !errors.As(err, &perr) || len(perr.Violations) != 1 || perr.Violations[0].Rule != RuleBreached || perr.Violations[0].Count != 104 {
+		t.Log("Expected breached violation, got", err)
+		t.FailNow()
+	}
+	if _, err = h.Hash("Tr0ub4dor&3-unbreached", testArgon2Params); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+}
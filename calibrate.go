@@ -0,0 +1,104 @@
+package password
+
+import (
+	"context"
+	"runtime"
+	"time"
+)
+
+// maxCalibrateN is the largest power of 2 accepted for ScryptParams:N
+const maxCalibrateN = 1 << 19
+
+// Calibration reports the Scrypt parameters found by Calibrate and their cost measured on the current machine
+type Calibration struct {
+	User   ScryptParams
+	Master ScryptParams
+	// UserDuration and MasterDuration are the measured time of a single Scrypt run with the respective parameters
+	UserDuration   time.Duration
+	MasterDuration time.Duration
+	// UserMemory and MasterMemory are the measured bytes allocated by a single Scrypt run with the respective parameters
+	UserMemory   uint64
+	MasterMemory uint64
+}
+
+// Calibrate takes target duration for a single Hash or Verify call and maximum memory in bytes for a single Scrypt run,
+// and returns the most costly user and master ScryptParams meeting that budget on the current machine. Hash and Verify
+// run both layers so each is calibrated separately to half of target, to calibrate a single layer, such as user
+// parameters for a Hasher which derives the master layer once, use CalibrateScrypt. N is increased in powers of 2
+// first, then R once N reaches its maximum, then P with any remaining time.
+func Calibrate(target time.Duration, maxMemory uint64) (c Calibration, err error) {
+	c.User, c.UserDuration, c.UserMemory, err = CalibrateScrypt(target/2, maxMemory)
+	if err != nil {
+		return
+	}
+	c.Master, c.MasterDuration, c.MasterMemory, err = calibrateScrypt(target/2, maxMemory, "calibratemasterpass")
+	return
+}
+
+// CalibrateScrypt takes target duration and maximum memory in bytes for a single Scrypt run, and returns the most
+// costly ScryptParams meeting that budget along with their measured duration, allocated memory and error.
+func CalibrateScrypt(target time.Duration, maxMemory uint64) (params ScryptParams, elapsed time.Duration, memory uint64, err error) {
+	// User passphrases are hashed as hex of Blake2b-512, calibrate with input of the same length
+	return calibrateScrypt(target, maxMemory, "calibrateuserpass000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000")
+}
+
+func calibrateScrypt(target time.Duration, maxMemory uint64, input string) (best ScryptParams, elapsed time.Duration, memory uint64, err error) {
+	// fits measures p and records it as best if it meets the budget
+	fits := func(p ScryptParams) (bool, error) {
+		if scryptMemory(p) > maxMemory {
+			return false, nil
+		}
+		d, m, err := measureScrypt(p, input)
+		if err != nil || d > target || m > maxMemory {
+			return false, err
+		}
+		best, elapsed, memory = p, d, m
+		return true, nil
+	}
+	p := ScryptParams{N: 4096, R: 8, P: 1}
+	ok, err := fits(p)
+	if err != nil {
+		return
+	}
+	if !ok {
+		return best, 0, 0, ErrCalibrationBudget
+	}
+	for p.N < maxCalibrateN {
+		p.N *= 2
+		if ok, err = fits(p); err != nil || !ok {
+			p.N /= 2
+			break
+		}
+	}
+	for p.N == maxCalibrateN && p.R < 128 {
+		p.R *= 2
+		if ok, err = fits(p); err != nil || !ok {
+			p.R /= 2
+			break
+		}
+	}
+	for p.P < 20 {
+		p.P++
+		if ok, err = fits(p); err != nil || !ok {
+			break
+		}
+	}
+	return best, elapsed, memory, err
+}
+
+// scryptMemory returns the approximate bytes Scrypt allocates for the given parameters
+func scryptMemory(p ScryptParams) uint64 {
+	return 128*uint64(p.N)*uint64(p.R) + 128*uint64(p.R)*uint64(p.P) + 256*uint64(p.R)
+}
+
+// measureScrypt returns the duration and allocated bytes of a single Scrypt run
+func measureScrypt(p ScryptParams, input string) (time.Duration, uint64, error) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	_, err := scryptHash(context.Background(), input, make([]byte, 8), p)
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	return elapsed, after.TotalAlloc - before.TotalAlloc, err
+}
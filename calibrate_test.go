@@ -0,0 +1,57 @@
+package password
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCalibrate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Calibration runs Scrypt repeatedly")
+	}
+	// The target is generous so that slow or instrumented builds, such as with -race, still fit the minimum
+	// parameters, only the properties of the result that do not depend on the machine are checked
+	target := 2 * time.Second
+	maxMemory := uint64(8 << 20)
+	c, err := Calibrate(target, maxMemory)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	fmt.Printf("Calibration Result: User N:%v R:%v P:%v in %v using %v bytes, Master N:%v R:%v P:%v in %v using %v bytes\n",
+		c.User.N, c.User.R, c.User.P, c.UserDuration, c.UserMemory, c.Master.N, c.Master.R, c.Master.P, c.MasterDuration, c.MasterMemory)
+
+	for _, p := range []ScryptParams{c.User, c.Master} {
+		if err := validateParams(p); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if p.N&(p.N-1) != 0 {
+			t.Log("Expected N to be a power of 2")
+			t.FailNow()
+		}
+		if scryptMemory(p) > maxMemory {
+			t.Log("Expected parameters within memory budget")
+			t.FailNow()
+		}
+	}
+	if c.UserMemory > maxMemory || c.MasterMemory > maxMemory {
+		t.Log("Unexpected measured memory")
+		t.FailNow()
+	}
+}
+
+func TestCalibrateBudget(t *testing.T) {
+	// Minimum parameters need 4MiB
+	_, err := Calibrate(time.Second, 1<<20)
+	if err != ErrCalibrationBudget {
+		t.Log("Expected calibration budget failure")
+		t.FailNow()
+	}
+	_, _, _, err = CalibrateScrypt(time.Nanosecond, 1<<30)
+	if err != ErrCalibrationBudget {
+		t.Log("Expected calibration budget failure")
+		t.FailNow()
+	}
+}
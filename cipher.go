@@ -0,0 +1,96 @@
+package password
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const (
+	// CipherSecretbox identifies NaCl secretbox (XSalsa20-Poly1305), the cipher of secBoxv1 and secBoxv2 hashes
+	CipherSecretbox = "secretbox"
+	// CipherXChaCha20Poly1305 identifies XChaCha20-Poly1305 with a random 24 byte nonce, as used by libsodium
+	CipherXChaCha20Poly1305 = "xchacha20poly1305"
+	// CipherAES256GCM identifies AES-256-GCM with a random 12 byte nonce. AES-256-GCM-SIV is not offered as it has no
+	// implementation in the Go standard library or golang.org/x/crypto. With a Hasher the key is shared across hashes,
+	// so as with any random 96 bit nonce no more than 2^32 hashes should be created per master salt.
+	CipherAES256GCM = "aes256gcm"
+)
+
+// cipherNonceSize returns the nonce length in bytes of the named cipher, all supported ciphers have a 16 byte tag
+func cipherNonceSize(name string) (int, error) {
+	switch name {
+	case CipherSecretbox, CipherXChaCha20Poly1305:
+		return 24, nil
+	case CipherAES256GCM:
+		return 12, nil
+	}
+	return 0, ErrUnsupportedCipher
+}
+
+// formatVersion returns the hash format version written for the named cipher, secBoxv2 for secretbox and secBoxv3
+// for the others
+func formatVersion(name string) int {
+	if name == CipherSecretbox {
+		return 2
+	}
+	return 3
+}
+
+// newAEAD returns the AEAD for ciphers other than secretbox
+func newAEAD(name string, key *[32]byte) (cipher.AEAD, error) {
+	switch name {
+	case CipherXChaCha20Poly1305:
+		return chacha20poly1305.NewX(key[:])
+	case CipherAES256GCM:
+		block, err := aes.NewCipher(key[:])
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	}
+	return nil, ErrUnsupportedCipher
+}
+
+// sealWith encrypts plaintext with the named cipher using a nonce read from random, which is prepended to the output
+func sealWith(random io.Reader, name string, key *[32]byte, plaintext []byte) ([]byte, error) {
+	if name == CipherSecretbox {
+		return seal(random, key, plaintext)
+	}
+	aead, err := newAEAD(name, key)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randBytes(random, aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// openWith decrypts output of sealWith for the named cipher
+func openWith(name string, key *[32]byte, encrypted []byte) ([]byte, error) {
+	if name == CipherSecretbox {
+		return open(key, encrypted)
+	}
+	aead, err := newAEAD(name, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrCiphertextFormat
+	}
+	decrypted, err := aead.Open(nil, encrypted[:aead.NonceSize()], encrypted[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrSecretBoxDecryptFail
+	}
+	return decrypted, nil
+}
+
+// validateCipher returns ErrUnsupportedCipher for unknown cipher names
+func validateCipher(name string) error {
+	_, err := cipherNonceSize(name)
+	return err
+}
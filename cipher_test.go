@@ -0,0 +1,110 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHashV3(t *testing.T) {
+	ciphers := []string{CipherSecretbox, CipherXChaCha20Poly1305, CipherAES256GCM}
+	for _, cipher := range ciphers {
+		output, err := HashV3("password1234", "masterpassphrase", 0, cipher, testArgon2Params, DefaultParams)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		prefix := "secBoxv3$0$" + cipher + "$argon2id$"
+		if cipher == CipherSecretbox {
+			prefix = "secBoxv2$0$argon2id$"
+		}
+		if !strings.HasPrefix(output, prefix) {
+			t.Logf("Unexpected output %s", output)
+			t.FailNow()
+		}
+		if err = Verify("password1234", "masterpassphrase", output); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if err = Verify("passw0rd1234", "masterpassphrase", output); err != ErrPassphraseHashMismatch {
+			t.Log("Expected passphrase mismatch")
+			t.FailNow()
+		}
+		if err = Verify("password1234", "masterpassphrase1", output); err != ErrSecretBoxDecryptFail {
+			t.Log("Expected decryption failure for wrong master passphrase")
+			t.FailNow()
+		}
+		updated, err := UpdateMaster("masterpassphrase1", "masterpassphrase", 1, output, DefaultParams)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if err = Verify("password1234", "masterpassphrase1", updated); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		phc, err := EncodePHC(updated)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if err = Verify("password1234", "masterpassphrase1", phc); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+	}
+
+	if _, err := HashV3("password1234", "masterpassphrase", 0, "rot13", testArgon2Params, DefaultParams); err != ErrUnsupportedCipher {
+		t.Log("Expected unsupported cipher error")
+		t.FailNow()
+	}
+	output, err := HashV3("password1234", "masterpassphrase", 0, CipherAES256GCM, testArgon2Params, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if _, err := ParseHash(strings.Replace(output, "aes256gcm", "rot13", 1)); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Log("Expected unsupported cipher error")
+		t.FailNow()
+	}
+}
+
+func TestHasherCipher(t *testing.T) {
+	h, err := NewHasher("masterpassphrase", 0, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	legacy, err := h.Hash("password1234", testArgon2Params)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err = h.SetCipher("rot13"); err != ErrUnsupportedCipher {
+		t.Log("Expected unsupported cipher error")
+		t.FailNow()
+	}
+	if err = h.SetCipher(CipherXChaCha20Poly1305); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	output, err := h.Hash("password1234", testArgon2Params)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if !strings.HasPrefix(output, "secBoxv3$0$xchacha20poly1305$") {
+		t.Logf("Unexpected output %s", output)
+		t.FailNow()
+	}
+	for _, hash := range []string{legacy, output} {
+		if err = h.Verify("password1234", hash); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+	}
+	if stale, _ := NeedsRehash(legacy, RehashPolicy{Cipher: h.Cipher()}); !stale {
+		t.Log("Expected secretbox hash to need rehash")
+		t.FailNow()
+	}
+}
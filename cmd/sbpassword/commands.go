@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	password "github.com/dwin/goSecretBoxPassword"
+)
+
+func (c *cli) hash(args []string) error {
+	fs := c.flagSet("hash")
+	master := masterSource{name: "master passphrase"}
+	master.register(fs, "", "SBPASSWORD_MASTER")
+	var user userParamsFlags
+	user.register(fs)
+	version := fs.Int("version", 0, "master passphrase `version`")
+	masterParams := fs.String("master-params", defaultMasterParams(), "master passphrase Scrypt `parameters`")
+	phc := fs.Bool("phc", false, "output hash in PHC string format")
+	cipher := fs.String("cipher", password.CipherSecretbox, "master layer `cipher`, secretbox, xchacha20poly1305 or aes256gcm")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	userparams, err := user.parse()
+	if err != nil {
+		return err
+	}
+	masterparams, err := parseMasterParams(*masterParams)
+	if err != nil {
+		return err
+	}
+	masterpass, err := c.master(master)
+	if err != nil {
+		return err
+	}
+	userpass, err := c.readSecret("Passphrase: ")
+	if err != nil {
+		return err
+	}
+	var out string
+	if *cipher == password.CipherSecretbox {
+		out, err = password.HashV2(userpass, masterpass, *version, userparams, masterparams)
+	} else {
+		out, err = password.HashV3(userpass, masterpass, *version, *cipher, userparams, masterparams)
+	}
+	if err != nil {
+		return err
+	}
+	if *phc {
+		if out, err = password.EncodePHC(out); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(c.stdout, out)
+	return nil
+}
+
+func (c *cli) verify(args []string) error {
+	fs := c.flagSet("verify")
+	master := masterSource{name: "master passphrase"}
+	master.register(fs, "", "SBPASSWORD_MASTER")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errNoHash
+	}
+	masterpass, err := c.master(master)
+	if err != nil {
+		return err
+	}
+	userpass, err := c.readSecret("Passphrase: ")
+	if err != nil {
+		return err
+	}
+	if err := password.Verify(userpass, masterpass, fs.Arg(0)); err != nil {
+		return err
+	}
+	fmt.Fprintln(c.stdout, "OK")
+	return nil
+}
+
+func (c *cli) inspect(args []string) error {
+	fs := c.flagSet("inspect")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	hashes, err := c.hashArgs(fs.Args())
+	if err != nil {
+		return err
+	}
+	if len(hashes) == 0 {
+		return errNoHash
+	}
+	for i, h := range hashes {
+		if i > 0 {
+			fmt.Fprintln(c.stdout)
+		}
+		parsed, err := password.ParseHash(h)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "format:         secBoxv%v\n", parsed.Version)
+		fmt.Fprintf(c.stdout, "master version: %v\n", parsed.MasterVersion)
+		fmt.Fprintf(c.stdout, "cipher:         %s\n", parsed.Cipher)
+		fmt.Fprintf(c.stdout, "user kdf:       %s\n", parsed.UserParams.KDF())
+		fmt.Fprintf(c.stdout, "user params:    %+v\n", parsed.UserParams)
+		fmt.Fprintf(c.stdout, "master params:  %+v\n", parsed.MasterParams)
+	}
+	return nil
+}
+
+func (c *cli) rotate(args []string) error {
+	fs := c.flagSet("rotate")
+	oldMaster := masterSource{name: "old master passphrase"}
+	oldMaster.register(fs, "old-", "SBPASSWORD_OLD_MASTER")
+	master := masterSource{name: "new master passphrase"}
+	master.register(fs, "", "SBPASSWORD_MASTER")
+	version := fs.Int("version", 1, "new master passphrase `version`")
+	masterParams := fs.String("master-params", defaultMasterParams(), "new master passphrase Scrypt `parameters`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	masterparams, err := parseMasterParams(*masterParams)
+	if err != nil {
+		return err
+	}
+	oldpass, err := c.master(oldMaster)
+	if err != nil {
+		return err
+	}
+	newpass, err := c.master(master)
+	if err != nil {
+		return err
+	}
+	hashes, err := c.hashArgs(fs.Args())
+	if err != nil {
+		return err
+	}
+	if len(hashes) == 0 {
+		return errNoHash
+	}
+	for _, h := range hashes {
+		out, err := password.UpdateMaster(newpass, oldpass, *version, h, masterparams)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.stdout, out)
+	}
+	return nil
+}
+
+func (c *cli) bench(args []string) error {
+	fs := c.flagSet("bench")
+	var user userParamsFlags
+	user.register(fs)
+	masterParams := fs.String("master-params", defaultMasterParams(), "master passphrase Scrypt `parameters`")
+	runs := fs.Int("runs", 5, "number of `runs` to average")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	userparams, err := user.parse()
+	if err != nil {
+		return err
+	}
+	masterparams, err := parseMasterParams(*masterParams)
+	if err != nil {
+		return err
+	}
+	if *runs < 1 {
+		*runs = 1
+	}
+	var total time.Duration
+	for i := 0; i < *runs; i++ {
+		start := time.Now()
+		if _, err := password.HashV2("benchmarkpass", "benchmarkmasterpass", 0, userparams, masterparams); err != nil {
+			return err
+		}
+		total += time.Since(start)
+	}
+	fmt.Fprintf(c.stdout, "%v average of %v runs, user %s %+v, master %+v\n", total/time.Duration(*runs), *runs, userparams.KDF(), userparams, masterparams)
+	return nil
+}
+
+func (c *cli) split(args []string) error {
+	fs := c.flagSet("split")
+	master := masterSource{name: "master passphrase"}
+	master.register(fs, "", "SBPASSWORD_MASTER")
+	shares := fs.Int("n", 5, "number of `shares`")
+	threshold := fs.Int("k", 3, "number of shares needed to combine, the `threshold`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	masterpass, err := c.master(master)
+	if err != nil {
+		return err
+	}
+	out, err := password.SplitMaster(masterpass, *shares, *threshold)
+	if err != nil {
+		return err
+	}
+	for _, s := range out {
+		fmt.Fprintln(c.stdout, s)
+	}
+	return nil
+}
+
+func (c *cli) combine(args []string) error {
+	fs := c.flagSet("combine")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	shares, err := c.hashArgs(fs.Args())
+	if err != nil {
+		return err
+	}
+	masterpass, err := password.CombineMaster(shares)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.stdout, masterpass)
+	return nil
+}
+
+func (c *cli) importBcrypt(args []string) error {
+	fs := c.flagSet("import")
+	master := masterSource{name: "master passphrase"}
+	master.register(fs, "", "SBPASSWORD_MASTER")
+	version := fs.Int("version", 0, "master passphrase `version`")
+	masterParams := fs.String("master-params", defaultMasterParams(), "master passphrase Scrypt `parameters`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	masterparams, err := parseMasterParams(*masterParams)
+	if err != nil {
+		return err
+	}
+	masterpass, err := c.master(master)
+	if err != nil {
+		return err
+	}
+	hashes, err := c.hashArgs(fs.Args())
+	if err != nil {
+		return err
+	}
+	if len(hashes) == 0 {
+		return errNoHash
+	}
+	h, err := password.NewHasher(masterpass, *version, masterparams)
+	if err != nil {
+		return err
+	}
+	for _, b := range hashes {
+		out, err := h.ImportBcrypt(b)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.stdout, out)
+	}
+	return nil
+}
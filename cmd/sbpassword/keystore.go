@@ -0,0 +1,224 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	password "github.com/dwin/goSecretBoxPassword"
+)
+
+const keystoreUsage = `usage: sbpassword keystore <command> [flags]
+
+commands:
+  create   create an empty keystore file
+  add      add a master passphrase version, read or generated
+  retire   remove a master passphrase version
+  current  set the current master passphrase version
+  passwd   change the operator passphrase
+  list     print versions and the current version
+`
+
+var errNoKeystore = errors.New("no keystore file given, use -file")
+
+// keystoreFlags holds the flags common to keystore commands
+type keystoreFlags struct {
+	file     string
+	operator masterSource
+}
+
+func (k *keystoreFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&k.file, "file", "", "keystore `file`")
+	k.operator.name = "operator passphrase"
+	fs.StringVar(&k.operator.file, "passphrase-file", "", "read operator passphrase from `file`")
+	fs.StringVar(&k.operator.env, "passphrase-env", "SBPASSWORD_KEYSTORE_PASSPHRASE", "read operator passphrase from environment `variable`")
+}
+
+// loadKeystore opens the keystore file with the operator passphrase
+func (c *cli) loadKeystore(k keystoreFlags) (*password.Keystore, error) {
+	if k.file == "" {
+		return nil, errNoKeystore
+	}
+	operatorpass, err := c.master(k.operator)
+	if err != nil {
+		return nil, err
+	}
+	return password.LoadKeystore(k.file, operatorpass)
+}
+
+func (c *cli) keystore(args []string) error {
+	if len(args) < 1 {
+		fmt.Fprint(c.stderr, keystoreUsage)
+		return flag.ErrHelp
+	}
+	commands := map[string]func([]string) error{
+		"create":  c.keystoreCreate,
+		"add":     c.keystoreAdd,
+		"retire":  c.keystoreRetire,
+		"current": c.keystoreCurrent,
+		"passwd":  c.keystorePasswd,
+		"list":    c.keystoreList,
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(c.stderr, "sbpassword keystore: unknown command %q\n\n%s", args[0], keystoreUsage)
+		return flag.ErrHelp
+	}
+	return cmd(args[1:])
+}
+
+func (c *cli) keystoreCreate(args []string) error {
+	fs := c.flagSet("keystore create")
+	var k keystoreFlags
+	k.register(fs)
+	params := fs.String("params", defaultMasterParams(), "operator passphrase Scrypt `parameters`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if k.file == "" {
+		return errNoKeystore
+	}
+	if _, err := os.Stat(k.file); err == nil {
+		return fmt.Errorf("%s already exists", k.file)
+	}
+	kekparams, err := parseMasterParams(*params)
+	if err != nil {
+		return err
+	}
+	operatorpass, err := c.master(k.operator)
+	if err != nil {
+		return err
+	}
+	ks, err := password.NewKeystore(operatorpass, kekparams)
+	if err != nil {
+		return err
+	}
+	return ks.Save(k.file)
+}
+
+func (c *cli) keystoreAdd(args []string) error {
+	fs := c.flagSet("keystore add")
+	var k keystoreFlags
+	k.register(fs)
+	master := masterSource{name: "master passphrase"}
+	master.register(fs, "", "SBPASSWORD_MASTER")
+	version := fs.Int("version", 0, "master passphrase `version`")
+	generate := fs.Bool("generate", false, "generate a random master passphrase rather than reading one")
+	current := fs.Bool("current", false, "make the version current")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ks, err := c.loadKeystore(k)
+	if err != nil {
+		return err
+	}
+	var masterpass string
+	if *generate {
+		b := make([]byte, 32)
+		if _, err = rand.Read(b); err != nil {
+			return err
+		}
+		masterpass = base64.RawStdEncoding.EncodeToString(b)
+	} else if masterpass, err = c.master(master); err != nil {
+		return err
+	}
+	if err = ks.AddVersion(*version, masterpass); err != nil {
+		return err
+	}
+	if *current {
+		if err = ks.SetCurrent(*version); err != nil {
+			return err
+		}
+	}
+	return ks.Save(k.file)
+}
+
+func (c *cli) keystoreRetire(args []string) error {
+	fs := c.flagSet("keystore retire")
+	var k keystoreFlags
+	k.register(fs)
+	version := fs.Int("version", 0, "master passphrase `version` to retire")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ks, err := c.loadKeystore(k)
+	if err != nil {
+		return err
+	}
+	if err = ks.Retire(*version); err != nil {
+		return err
+	}
+	return ks.Save(k.file)
+}
+
+func (c *cli) keystoreCurrent(args []string) error {
+	fs := c.flagSet("keystore current")
+	var k keystoreFlags
+	k.register(fs)
+	version := fs.Int("version", 0, "master passphrase `version` to make current")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ks, err := c.loadKeystore(k)
+	if err != nil {
+		return err
+	}
+	if err = ks.SetCurrent(*version); err != nil {
+		return err
+	}
+	return ks.Save(k.file)
+}
+
+func (c *cli) keystorePasswd(args []string) error {
+	fs := c.flagSet("keystore passwd")
+	var k keystoreFlags
+	k.register(fs)
+	newOperator := masterSource{name: "new operator passphrase"}
+	fs.StringVar(&newOperator.file, "new-passphrase-file", "", "read new operator passphrase from `file`")
+	fs.StringVar(&newOperator.env, "new-passphrase-env", "SBPASSWORD_NEW_KEYSTORE_PASSPHRASE", "read new operator passphrase from environment `variable`")
+	params := fs.String("params", defaultMasterParams(), "new operator passphrase Scrypt `parameters`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	kekparams, err := parseMasterParams(*params)
+	if err != nil {
+		return err
+	}
+	ks, err := c.loadKeystore(k)
+	if err != nil {
+		return err
+	}
+	operatorpass, err := c.master(newOperator)
+	if err != nil {
+		return err
+	}
+	if err = ks.ChangePassphrase(operatorpass, kekparams); err != nil {
+		return err
+	}
+	return ks.Save(k.file)
+}
+
+func (c *cli) keystoreList(args []string) error {
+	fs := c.flagSet("keystore list")
+	var k keystoreFlags
+	k.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ks, err := c.loadKeystore(k)
+	if err != nil {
+		return err
+	}
+	current, err := ks.CurrentVersion()
+	for _, v := range ks.Versions() {
+		if err == nil && v == current {
+			fmt.Fprintf(c.stdout, "%v (current)\n", v)
+		} else {
+			fmt.Fprintln(c.stdout, v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+// Command sbpassword hashes, verifies, inspects and rotates goSecretBoxPassword hashes.
+//
+// Usage:
+//
+//	sbpassword <command> [flags] [hash ...]
+//
+// Commands:
+//
+//	hash     hash a passphrase read from stdin or prompt
+//	verify   verify a passphrase read from stdin or prompt against a hash
+//	inspect  print format version, master version and parameters of hashes
+//	rotate   re-encrypt hashes under a new master passphrase and version
+//	import   wrap existing bcrypt hashes under a master passphrase
+//	bench    time hashing with the given parameters
+//	split    split a master passphrase into shares, any threshold of which recombine it
+//	combine  recombine a master passphrase from shares given as arguments or read from stdin
+//	keystore create and manage a keystore of master passphrases sealed under an operator passphrase
+//
+// Master passphrases are never read from arguments. They are read from the file given by -master-file, or the
+// environment variable named by -master-env (default SBPASSWORD_MASTER), or else prompted for. Passphrases are
+// prompted for without echo when stdin is a terminal, otherwise they are read one per line from stdin.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	password "github.com/dwin/goSecretBoxPassword"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const usage = `usage: sbpassword <command> [flags] [hash ...]
+
+commands:
+  hash     hash a passphrase read from stdin or prompt
+  verify   verify a passphrase read from stdin or prompt against a hash
+  inspect  print format version, master version and parameters of hashes
+  rotate   re-encrypt hashes under a new master passphrase and version
+  import   wrap existing bcrypt hashes under a master passphrase
+  bench    time hashing with the given parameters
+  split    split a master passphrase into shares, any threshold of which recombine it
+  combine  recombine a master passphrase from shares given as arguments or read from stdin
+  keystore create and manage a keystore of master passphrases sealed under an operator passphrase
+
+Run 'sbpassword <command> -h' for command flags.
+`
+
+func main() {
+	os.Exit(newCLI(os.Stdin, os.Stdout, os.Stderr, os.Getenv).run(os.Args[1:]))
+}
+
+// cli holds the input and output streams so that commands can be run in tests
+type cli struct {
+	stdin  io.Reader
+	in     *bufio.Reader
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+}
+
+func newCLI(stdin io.Reader, stdout, stderr io.Writer, getenv func(string) string) *cli {
+	return &cli{stdin: stdin, in: bufio.NewReader(stdin), stdout: stdout, stderr: stderr, getenv: getenv}
+}
+
+func (c *cli) run(args []string) int {
+	if len(args) < 1 {
+		fmt.Fprint(c.stderr, usage)
+		return 2
+	}
+	commands := map[string]func([]string) error{
+		"hash":     c.hash,
+		"verify":   c.verify,
+		"inspect":  c.inspect,
+		"rotate":   c.rotate,
+		"bench":    c.bench,
+		"import":   c.importBcrypt,
+		"split":    c.split,
+		"combine":  c.combine,
+		"keystore": c.keystore,
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(c.stderr, "sbpassword: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+	err := cmd(args[1:])
+	if err == flag.ErrHelp {
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(c.stderr, "sbpassword %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func (c *cli) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+// masterSource holds the flags selecting where a master passphrase is read from
+type masterSource struct {
+	name string
+	file string
+	env  string
+}
+
+func (m *masterSource) register(fs *flag.FlagSet, prefix, defaultEnv string) {
+	fs.StringVar(&m.file, prefix+"master-file", "", "read "+m.name+" from `file`")
+	fs.StringVar(&m.env, prefix+"master-env", defaultEnv, "read "+m.name+" from environment `variable`")
+}
+
+// master returns the master passphrase from file, environment or prompt in that order. Files are read as by
+// password.FileProvider, so a file readable by group or others is refused with ErrKeyFilePermissions.
+func (c *cli) master(m masterSource) (string, error) {
+	if m.file != "" {
+		return password.NewFileProvider(0, map[int]string{0: m.file}).GetKey(0)
+	}
+	if m.env != "" {
+		if v := c.getenv(m.env); v != "" {
+			return v, nil
+		}
+	}
+	return c.readSecret(strings.ToUpper(m.name[:1]) + m.name[1:] + ": ")
+}
+
+// readSecret prompts for a secret without echo when stdin is a terminal, otherwise it reads a line from stdin
+func (c *cli) readSecret(prompt string) (string, error) {
+	if f, ok := c.stdin.(*os.File); ok && terminal.IsTerminal(int(f.Fd())) {
+		fmt.Fprint(c.stderr, prompt)
+		b, err := terminal.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(c.stderr)
+		return string(b), err
+	}
+	return c.readLine()
+}
+
+func (c *cli) readLine() (string, error) {
+	line, err := c.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// userParamsFlags holds the flags selecting the user passphrase KDF
+type userParamsFlags struct {
+	kdf    string
+	params string
+	norm   string
+}
+
+func (u *userParamsFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&u.kdf, "kdf", password.KDFArgon2id, "user passphrase `kdf`, argon2id, scrypt, pbkdf2-sha256 or pbkdf2-sha512")
+	fs.StringVar(&u.norm, "norm", "", "normalize passphrases with `form` nfc, nfkc or opaquestring before hashing")
+	fs.StringVar(&u.params, "params", "", "user passphrase KDF `parameters`, ex. m=65536,t=3,p=4,l=32 or n=32768,r=16,p=1 (default recommended)")
+}
+
+func (u *userParamsFlags) parse() (password.UserParams, error) {
+	p, err := u.parseKDF()
+	if err != nil || u.norm == "" {
+		return p, err
+	}
+	return password.NormalizedParams{Params: p, Form: u.norm}, nil
+}
+
+func (u *userParamsFlags) parseKDF() (password.UserParams, error) {
+	if u.params != "" {
+		return password.ParseUserParams(u.kdf, u.params)
+	}
+	switch u.kdf {
+	case password.KDFArgon2id:
+		return password.DefaultArgon2Params, nil
+	case password.KDFScrypt:
+		return password.ScryptParams{N: 32768, R: 16, P: 1}, nil
+	case password.KDFPBKDF2SHA256:
+		return password.DefaultPBKDF2Params, nil
+	case password.KDFPBKDF2SHA512:
+		return password.PBKDF2Params{Hash: "sha512", Iterations: 210000, KeyLen: 64}, nil
+	}
+	return nil, password.ErrUnsupportedKDF
+}
+
+func parseMasterParams(s string) (password.ScryptParams, error) {
+	p, err := password.ParseUserParams(password.KDFScrypt, s)
+	if err != nil {
+		return password.ScryptParams{}, err
+	}
+	return p.(password.ScryptParams), nil
+}
+
+func defaultMasterParams() string {
+	return fmt.Sprintf("n=%v,r=%v,p=%v", password.DefaultParams.N, password.DefaultParams.R, password.DefaultParams.P)
+}
+
+// hashArgs returns hashes, or shares, given as arguments, or read one per line from stdin if none were given
+func (c *cli) hashArgs(args []string) ([]string, error) {
+	if len(args) > 0 {
+		return args, nil
+	}
+	var hashes []string
+	for {
+		line, err := c.readLine()
+		if line != "" {
+			hashes = append(hashes, line)
+		}
+		if err == io.EOF {
+			return hashes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+var errNoHash = errors.New("no hash given")
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	password "github.com/dwin/goSecretBoxPassword"
+)
+
+func testEnv(env map[string]string) func(string) string {
+	return func(k string) string { return env[k] }
+}
+
+func runCLI(stdin string, env map[string]string, args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	code = newCLI(strings.NewReader(stdin), &out, &errOut, testEnv(env)).run(args)
+	return code, out.String(), errOut.String()
+}
+
+func TestCLI(t *testing.T) {
+	env := map[string]string{"SBPASSWORD_MASTER": "masterpassphrase"}
+	code, out, stderr := runCLI("password1234\n", env, "hash", "-params", "m=8192,t=1,p=1,l=32")
+	if code != 0 {
+		t.Log(stderr)
+		t.FailNow()
+	}
+	hash := strings.TrimSpace(out)
+
+	if code, _, stderr := runCLI("password1234\n", env, "verify", hash); code != 0 {
+		t.Log(stderr)
+		t.FailNow()
+	}
+	if code, _, _ := runCLI("passw0rd1234\n", env, "verify", hash); code != 1 {
+		t.Log("Expected verify failure")
+		t.FailNow()
+	}
+
+	code, out, stderr = runCLI("", nil, "inspect", hash)
+	if code != 0 {
+		t.Log(stderr)
+		t.FailNow()
+	}
+	if !strings.Contains(out, "format:         secBoxv2") || !strings.Contains(out, "user kdf:       argon2id") {
+		t.Logf("Unexpected inspect output: %s", out)
+		t.FailNow()
+	}
+
+	// Master passphrases are read from environment, hashes from stdin
+	env["SBPASSWORD_OLD_MASTER"] = "masterpassphrase"
+	env["SBPASSWORD_MASTER"] = "masterpassphrase2"
+	code, out, stderr = runCLI(hash+"\n", env, "rotate", "-version", "1")
+	if code != 0 {
+		t.Log(stderr)
+		t.FailNow()
+	}
+	rotated := strings.TrimSpace(out)
+	if code, _, stderr := runCLI("password1234\n", env, "verify", rotated); code != 0 {
+		t.Log(stderr)
+		t.FailNow()
+	}
+}
+
+func TestCLICipher(t *testing.T) {
+	env := map[string]string{"SBPASSWORD_MASTER": "masterpassphrase"}
+	code, out, stderr := runCLI("password1234\n", env, "hash", "-cipher", "aes256gcm", "-params", "m=8192,t=1,p=1,l=32")
+	if code != 0 {
+		t.Log(stderr)
+		t.FailNow()
+	}
+	hash := strings.TrimSpace(out)
+	if code, _, stderr := runCLI("password1234\n", env, "verify", hash); code != 0 {
+		t.Log(stderr)
+		t.FailNow()
+	}
+	if code, out, _ := runCLI("", nil, "inspect", hash); code != 0 || !strings.Contains(out, "cipher:         aes256gcm") {
+		t.Logf("Unexpected inspect output: %s", out)
+		t.FailNow()
+	}
+	if code, _, _ := runCLI("password1234\n", env, "hash", "-cipher", "rot13"); code != 1 {
+		t.Log("Expected unknown cipher failure")
+		t.FailNow()
+	}
+}
+
+func TestCLIPHC(t *testing.T) {
+	env := map[string]string{"SBPASSWORD_MASTER": "masterpassphrase"}
+	code, out, stderr := runCLI("password1234\n", env, "hash", "-phc", "-params", "m=8192,t=1,p=1,l=32")
+	if code != 0 {
+		t.Log(stderr)
+		t.FailNow()
+	}
+	hash := strings.TrimSpace(out)
+	if !strings.HasPrefix(hash, "$secbox$v=2$") {
+		t.Logf("Expected PHC output, got %s", hash)
+		t.FailNow()
+	}
+	if code, _, stderr := runCLI("password1234\n", env, "verify", hash); code != 0 {
+		t.Log(stderr)
+		t.FailNow()
+	}
+	if code, out, stderr := runCLI("", nil, "inspect", hash); code != 0 || !strings.Contains(out, "format:         secBoxv2") {
+		t.Log(stderr)
+		t.FailNow()
+	}
+}
+
+func TestCLIMasterPrompt(t *testing.T) {
+	// Without environment both master and user passphrase are read from stdin
+	code, out, stderr := runCLI("masterpassphrase\npassword1234\n", nil, "hash", "-kdf", "scrypt", "-params", "n=16384,r=8,p=1")
+	if code != 0 {
+		t.Log(stderr)
+		t.FailNow()
+	}
+	hash := strings.TrimSpace(out)
+	if code, _, stderr := runCLI("masterpassphrase\npassword1234\n", nil, "verify", hash); code != 0 {
+		t.Log(stderr)
+		t.FailNow()
+	}
+}
+
+func TestCLIMasterFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "master")
+	if err := os.WriteFile(file, []byte("masterpassphrase\n"), 0600); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if code, _, stderr := runCLI("password1234\n", nil, "hash", "-master-file", file, "-params", "m=8192,t=1,p=1,l=32"); code != 0 {
+		t.Log(stderr)
+		t.FailNow()
+	}
+	if runtime.GOOS == "windows" {
+		return
+	}
+	// Files readable by group or others are refused
+	if err := os.Chmod(file, 0644); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	code, _, stderr := runCLI("password1234\n", nil, "hash", "-master-file", file, "-params", "m=8192,t=1,p=1,l=32")
+	if code != 1 || !strings.Contains(stderr, password.ErrKeyFilePermissions.Error()) {
+		t.Logf("Expected permissions failure, got %v: %s", code, stderr)
+		t.FailNow()
+	}
+}
+
+func TestCLIBadInput(t *testing.T) {
+	if code, _, _ := runCLI("", nil); code != 2 {
+		t.Log("Expected usage exit code")
+		t.FailNow()
+	}
+	if code, _, _ := runCLI("", nil, "unknown"); code != 2 {
+		t.Log("Expected usage exit code")
+		t.FailNow()
+	}
+	if code, _, _ := runCLI("", nil, "inspect", "secBoxv1"); code != 1 {
+		t.Log("Expected inspect failure")
+		t.FailNow()
+	}
+	if code, _, _ := runCLI("", nil, "hash", "-kdf", "bcrypt"); code != 1 {
+		t.Log("Expected unsupported KDF failure")
+		t.FailNow()
+	}
+	if code, _, _ := runCLI("", nil, "verify"); code != 1 {
+		t.Log("Expected missing hash failure")
+		t.FailNow()
+	}
+}
+
+func TestCLIKeystore(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "keystore.json")
+	env := map[string]string{"SBPASSWORD_KEYSTORE_PASSPHRASE": "operatorpassphrase", "SBPASSWORD_MASTER": "masterpassphrase"}
+	steps := [][]string{
+		{"keystore", "create", "-file", file},
+		{"keystore", "add", "-file", file, "-version", "0"},
+		{"keystore", "add", "-file", file, "-version", "1", "-generate", "-current"},
+		{"keystore", "retire", "-file", file, "-version", "0"},
+	}
+	for _, args := range steps {
+		if code, _, stderr := runCLI("", env, args...); code != 0 {
+			t.Log(args, stderr)
+			t.FailNow()
+		}
+	}
+	env["SBPASSWORD_NEW_KEYSTORE_PASSPHRASE"] = "newoperatorpassphrase"
+	if code, _, stderr := runCLI("", env, "keystore", "passwd", "-file", file); code != 0 {
+		t.Log(stderr)
+		t.FailNow()
+	}
+	if code, _, _ := runCLI("", env, "keystore", "list", "-file", file); code != 1 {
+		t.Log("Expected old operator passphrase to fail")
+		t.FailNow()
+	}
+	env["SBPASSWORD_KEYSTORE_PASSPHRASE"] = "newoperatorpassphrase"
+	code, out, stderr := runCLI("", env, "keystore", "list", "-file", file)
+	if code != 0 || out != "1 (current)\n" {
+		t.Logf("Unexpected list output: %s %s", out, stderr)
+		t.FailNow()
+	}
+}
+
+func TestCLIShares(t *testing.T) {
+	env := map[string]string{"SBPASSWORD_MASTER": "masterpassphrase"}
+	code, out, stderr := runCLI("", env, "split", "-n", "3", "-k", "2")
+	if code != 0 {
+		t.Log(stderr)
+		t.FailNow()
+	}
+	shares := strings.Fields(out)
+	if len(shares) != 3 {
+		t.Logf("Unexpected split output: %s", out)
+		t.FailNow()
+	}
+	code, out, stderr = runCLI(shares[2]+"\n"+shares[0]+"\n", nil, "combine")
+	if code != 0 || out != "masterpassphrase\n" {
+		t.Logf("Unexpected combine output: %s %s", out, stderr)
+		t.FailNow()
+	}
+	if code, _, _ := runCLI("", nil, "combine", shares[1]); code != 1 {
+		t.Log("Expected combine below threshold to fail")
+		t.FailNow()
+	}
+}
+
+func TestCLIImport(t *testing.T) {
+	env := map[string]string{"SBPASSWORD_MASTER": "masterpassphrase"}
+	// bcrypt of "password1234" with cost 4
+	legacy := "$2a$04$10jc2xSblZsd9R3yRiymqudnx3smMYfZ2RcNlM66jrVOPR9W.CYaS"
+	code, out, stderr := runCLI(legacy+"\n", env, "import")
+	if code != 0 {
+		t.Log(stderr)
+		t.FailNow()
+	}
+	if code, _, stderr := runCLI("password1234\n", env, "verify", strings.TrimSpace(out)); code != 0 {
+		t.Log(stderr)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,154 @@
+package password
+
+import (
+	"context"
+	"io"
+)
+
+// contextDo runs fn, returning its error or ctx.Err() as soon as ctx is done. Scrypt and Argon2 cannot be interrupted,
+// so fn runs in a goroutine which finishes in the background after cancellation with its result discarded. Callers
+// check ctx between KDF stages so that no further stage is started.
+func contextDo(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// Never cancelled, ex. context.Background()
+	if ctx.Done() == nil {
+		return fn()
+	}
+	// Buffered so the goroutine can always deliver its result and exit
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// HashContext is HashV2 with ctx checked before each KDF stage. If ctx is cancelled or its deadline passes ctx.Err()
+// is returned without waiting for the running KDF to finish.
+func HashContext(ctx context.Context, userpass, masterpass string, version int, userparams UserParams, masterparams ScryptParams) (pwHashOut string, err error) {
+	if len(userpass) < MinLength {
+		return "", ErrPassphraseLength
+	}
+	if len(masterpass) < MinLength {
+		return "", ErrPassphraseLength
+	}
+	err = validateUser(userparams)
+	if err != nil {
+		return
+	}
+	err = validateMaster(masterparams)
+	if err != nil {
+		return
+	}
+	var userpassHash []byte
+	err = contextDo(ctx, func() (err error) {
+		userpassHash, err = userparams.hash(ctx, nil, userpass)
+		return
+	})
+	if err != nil {
+		return "", err
+	}
+	out := &ParsedHash{
+		Version:       2,
+		MasterVersion: version,
+		Cipher:        CipherSecretbox,
+		UserParams:    userparams,
+		MasterParams:  masterparams,
+	}
+	var key [32]byte
+	err = contextDo(ctx, func() (err error) {
+		key, out.MasterSalt, err = newMasterKey(ctx, nil, masterpass, masterparams)
+		return
+	})
+	if err != nil {
+		return "", err
+	}
+	if err = out.seal(nil, &key, userpassHash); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// VerifyContext is Verify with ctx checked before each KDF stage. If ctx is cancelled or its deadline passes
+// ctx.Err() is returned without waiting for the running KDF to finish.
+func VerifyContext(ctx context.Context, userpass, masterpass, ciphertext string) error {
+	parsed, err := ParseHash(ciphertext)
+	if err != nil {
+		return err
+	}
+	var decrypted []byte
+	err = contextDo(ctx, func() error {
+		key, err := masterKey(ctx, masterpass, parsed.MasterSalt, parsed.MasterParams)
+		if err != nil {
+			return err
+		}
+		decrypted, err = parsed.open(&key)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return contextDo(ctx, func() error {
+		return parsed.UserParams.verify(ctx, userpass, decrypted)
+	})
+}
+
+// UpdateMasterContext is UpdateMaster with ctx checked before each KDF stage. If ctx is cancelled or its deadline
+// passes ctx.Err() is returned without waiting for the running KDF to finish.
+func UpdateMasterContext(ctx context.Context, newMaster, oldMaster string, newVersion int, ciphertext string, masterparams ScryptParams) (newHash string, err error) {
+	parsed, err := ParseHash(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	if newVersion <= parsed.MasterVersion {
+		return "", ErrInvalidVersionUpdate
+	}
+	err = validateMaster(masterparams)
+	if err != nil {
+		return "", err
+	}
+	var decrypted []byte
+	err = contextDo(ctx, func() error {
+		key, err := masterKey(ctx, oldMaster, parsed.MasterSalt, parsed.MasterParams)
+		if err != nil {
+			return err
+		}
+		decrypted, err = parsed.open(&key)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	var key [32]byte
+	var salt []byte
+	err = contextDo(ctx, func() (err error) {
+		key, salt, err = newMasterKey(ctx, nil, newMaster, masterparams)
+		return
+	})
+	if err != nil {
+		return "", err
+	}
+	parsed.MasterVersion = newVersion
+	parsed.MasterParams = masterparams
+	parsed.MasterSalt = salt
+	if err = parsed.seal(nil, &key, decrypted); err != nil {
+		return "", err
+	}
+	return parsed.String(), nil
+}
+
+// newMasterKey reads a master salt from random, or crypto/rand if nil, and derives the master key from it
+func newMasterKey(ctx context.Context, random io.Reader, masterpass string, masterparams ScryptParams) (key [32]byte, salt []byte, err error) {
+	salt, err = randBytes(random, 8)
+	if err != nil {
+		return key, nil, err
+	}
+	key, err = masterKey(ctx, masterpass, salt, masterparams)
+	return key, salt, err
+}
@@ -0,0 +1,92 @@
+package password
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHashContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	output, err := HashContext(ctx, "password1234", "masterpassphrase", 0, testArgon2Params, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err = Verify("password1234", "masterpassphrase", output); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err = VerifyContext(ctx, "password1234", "masterpassphrase", output); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err = VerifyContext(ctx, "passw0rd1234", "masterpassphrase", output); err != ErrPassphraseHashMismatch {
+		t.Log("Expected passphrase mismatch")
+		t.FailNow()
+	}
+	updated, err := UpdateMasterContext(ctx, "masterpassphrase1", "masterpassphrase", 1, output, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err = Verify("password1234", "masterpassphrase1", updated); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	// Cancelled before starting, no KDF is run
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	if _, err = HashContext(cancelled, "password1234", "masterpassphrase", 0, testArgon2Params, DefaultParams); err != context.Canceled {
+		t.Log("Expected context.Canceled")
+		t.FailNow()
+	}
+	if err = VerifyContext(cancelled, "password1234", "masterpassphrase", output); err != context.Canceled {
+		t.Log("Expected context.Canceled")
+		t.FailNow()
+	}
+	if _, err = UpdateMasterContext(cancelled, "masterpassphrase1", "masterpassphrase", 1, output, DefaultParams); err != context.Canceled {
+		t.Log("Expected context.Canceled")
+		t.FailNow()
+	}
+
+	// Deadline passes while the KDF is running
+	slow := ScryptParams{N: 65536, R: 8, P: 8}
+	short, cancelShort := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelShort()
+	start := time.Now()
+	if _, err = HashContext(short, "password1234", "masterpassphrase", 0, slow, slow); err != context.DeadlineExceeded {
+		t.Logf("Expected context.DeadlineExceeded, got %v", err)
+		t.FailNow()
+	}
+	t.Logf("Returned after %v", time.Since(start))
+}
+
+func TestHasherContext(t *testing.T) {
+	k, err := NewKeyring(1, DefaultParams, map[int]string{0: "masterpassphrase", 1: "masterpassphrase1"})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	output, err := k.HashContext(ctx, "password1234", testArgon2Params)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err = k.VerifyContext(ctx, "password1234", output); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	cancel()
+	if _, err = k.HashContext(ctx, "password1234", testArgon2Params); err != context.Canceled {
+		t.Log("Expected context.Canceled")
+		t.FailNow()
+	}
+	if err = k.VerifyContext(ctx, "password1234", output); err != context.Canceled {
+		t.Log("Expected context.Canceled")
+		t.FailNow()
+	}
+}
@@ -1,6 +1,9 @@
 package password
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// ErrCiphertextVer indicates version sub-string mismatch normally; ex. "secBoxv1"
@@ -13,6 +16,10 @@ var (
 	ErrPassphraseHashMismatch = errors.New("Passphrase hash does not match supplied ciphertext")
 	// ErrPassphraseLength indicates supplied passphrase is not at least MinLength
 	ErrPassphraseLength = errors.New("Passphrase must be at least MinLength")
+	// ErrPolicyViolation indicates a passphrase fails a Policy, see PolicyError for the rules failed
+	ErrPolicyViolation = errors.New("Passphrase does not meet policy")
+	// ErrBreachCorpus indicates a breached password corpus or range API response could not be read
+	ErrBreachCorpus = errors.New("Breached password corpus malformed or unavailable")
 	// ErrSecretBoxDecryptFail indicates SecretBox decryption could not be completed
 	ErrSecretBoxDecryptFail = errors.New("SecretBox decryption failed")
 	// ErrScryptParamN indicates ScryptParams:N out of acceptable range
@@ -21,4 +28,127 @@ var (
 	ErrScryptParamR = errors.New("Given Scrypt (r) cost factor out of acceptable range")
 	// ErrScryptParamP indicates ScryptParams:p out of acceptable range
 	ErrScryptParamP = errors.New("Given Scrypt (p) cost factor out of acceptable range")
+	// ErrArgon2ParamTime indicates Argon2Params:Time out of acceptable range
+	ErrArgon2ParamTime = errors.New("Given Argon2 (time) cost factor out of acceptable range")
+	// ErrArgon2ParamMemory indicates Argon2Params:Memory out of acceptable range
+	ErrArgon2ParamMemory = errors.New("Given Argon2 (memory) cost factor out of acceptable range")
+	// ErrArgon2ParamThreads indicates Argon2Params:Threads out of acceptable range
+	ErrArgon2ParamThreads = errors.New("Given Argon2 (threads) parallelism out of acceptable range")
+	// ErrArgon2ParamKeyLen indicates Argon2Params:KeyLen out of acceptable range
+	ErrArgon2ParamKeyLen = errors.New("Given Argon2 key length out of acceptable range")
+	// ErrPBKDF2ParamIterations indicates PBKDF2Params:Iterations out of acceptable range
+	ErrPBKDF2ParamIterations = errors.New("Given PBKDF2 iteration count out of acceptable range")
+	// ErrPBKDF2ParamKeyLen indicates PBKDF2Params:KeyLen out of acceptable range
+	ErrPBKDF2ParamKeyLen = errors.New("Given PBKDF2 key length out of acceptable range")
+	// ErrBcryptParamCost indicates BcryptParams:Cost out of acceptable range
+	ErrBcryptParamCost = errors.New("Given bcrypt cost factor out of acceptable range")
+	// ErrBcryptHash indicates the hash given to ImportBcrypt is not a bcrypt hash
+	ErrBcryptHash = errors.New("Invalid bcrypt hash")
+	// ErrCalibrationBudget indicates the minimum Scrypt parameters exceed the given duration or memory budget
+	ErrCalibrationBudget = errors.New("Minimum Scrypt parameters exceed given duration or memory budget")
+	// ErrRehashPolicy indicates a RehashPolicy the current Hasher of a Keyring cannot write hashes for
+	ErrRehashPolicy = errors.New("Rehash policy cannot be met by the current master passphrase version")
+	// ErrUnknownMasterVersion indicates no master passphrase is available for the version found in ciphertext
+	ErrUnknownMasterVersion = errors.New("No master passphrase available for ciphertext master version")
+	// ErrDuplicateMasterVersion indicates a master passphrase was already added for the given version
+	ErrDuplicateMasterVersion = errors.New("Master passphrase version already exists")
+	// ErrUnsupportedKDF indicates user passphrase KDF is unknown or not supported by the called function
+	ErrUnsupportedKDF = errors.New("Unsupported user passphrase KDF")
+	// ErrUnsupportedNormalization indicates the passphrase normalization form is unknown
+	ErrUnsupportedNormalization = errors.New("Unsupported passphrase normalization form")
+	// ErrPassphraseNormalization indicates the passphrase contains characters disallowed by the PRECIS OpaqueString profile
+	ErrPassphraseNormalization = errors.New("Passphrase contains disallowed characters")
+	// ErrUnsupportedCipher indicates the master layer cipher is unknown
+	ErrUnsupportedCipher = errors.New("Unsupported master layer cipher")
+	// ErrOverloaded indicates the Limiter queue is full or the wait for admission timed out
+	ErrOverloaded = errors.New("Too many concurrent hash computations, try again later")
+	// ErrKeyFilePermissions indicates a master passphrase file is readable or writable by group or others
+	ErrKeyFilePermissions = errors.New("Master passphrase file permissions too open")
+	// ErrKeystoreFormat indicates a Keystore could not be decoded
+	ErrKeystoreFormat = errors.New("Keystore format invalid")
+	// ErrKeystorePassphrase indicates the Keystore operator passphrase is wrong or a sealed master passphrase was altered
+	ErrKeystorePassphrase = errors.New("Keystore passphrase incorrect or keystore corrupted")
+	// ErrShareThreshold indicates invalid share count or threshold, or fewer shares than the threshold to combine
+	ErrShareThreshold = errors.New("Share threshold invalid or not enough shares")
+	// ErrShareFormat indicates a master passphrase share could not be decoded
+	ErrShareFormat = errors.New("Share format invalid")
+	// ErrShareChecksum indicates a master passphrase share was mistyped or corrupted
+	ErrShareChecksum = errors.New("Share checksum mismatch")
+	// ErrShareMismatch indicates shares are duplicated or come from different splits
+	ErrShareMismatch = errors.New("Shares duplicated or from different splits")
+)
+
+const (
+	// LayerUser identifies the user passphrase KDF in ParamError
+	LayerUser = "user"
+	// LayerMaster identifies the master passphrase Scrypt hash in ParamError
+	LayerMaster = "master"
 )
+
+// ParamError describes a KDF parameter out of its accepted range. It unwraps to the sentinel for the parameter,
+// ex. ErrScryptParamN, so it matches it with errors.Is.
+type ParamError struct {
+	// Layer is LayerUser or LayerMaster, or empty if not known
+	Layer string
+	// Param names the parameter, ex. "N" or "Memory"
+	Param string
+	Value int64
+	Min   int64
+	Max   int64
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	param := e.Param
+	if e.Layer != "" {
+		param = e.Layer + " " + param
+	}
+	return fmt.Sprintf("%v: %s is %v, must be %v to %v", e.Err, param, e.Value, e.Min, e.Max)
+}
+
+// Unwrap returns the sentinel for the parameter
+func (e *ParamError) Unwrap() error { return e.Err }
+
+// withLayer sets the layer of a *ParamError, other errors are returned unchanged
+func withLayer(err error, layer string) error {
+	if perr, ok := err.(*ParamError); ok && perr.Layer == "" {
+		layered := *perr
+		layered.Layer = layer
+		return &layered
+	}
+	return err
+}
+
+// RandError indicates the random source failed, no hash is produced. It unwraps to the read error.
+type RandError struct {
+	Err error
+}
+
+func (e *RandError) Error() string {
+	return fmt.Sprintf("Random source read failed: %v", e.Err)
+}
+
+// Unwrap returns the read error
+func (e *RandError) Unwrap() error { return e.Err }
+
+// FormatError describes a malformed field of a hash. It matches ErrCiphertextFormat with errors.Is and unwraps to its cause.
+type FormatError struct {
+	// Field names the malformed field, ex. "master salt"
+	Field string
+	// Index is the zero based "$" separated field index, or -1 if not applicable
+	Index int
+	Cause error
+}
+
+func (e *FormatError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("%v: %s: %v", ErrCiphertextFormat, e.Field, e.Cause)
+	}
+	return fmt.Sprintf("%v: field %v (%s): %v", ErrCiphertextFormat, e.Index, e.Field, e.Cause)
+}
+
+// Unwrap returns the cause of the error
+func (e *FormatError) Unwrap() error { return e.Cause }
+
+// Is reports whether target is ErrCiphertextFormat
+func (e *FormatError) Is(target error) bool { return target == ErrCiphertextFormat }
@@ -0,0 +1,69 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParamError(t *testing.T) {
+	_, err := Hash("password1234", "masterpassphrase", 0, DefaultParams, ScryptParams{N: 2048, R: 8, P: 1})
+	var perr *ParamError
+	if !errors.As(err, &perr) || perr.Layer != LayerMaster || perr.Param != "N" || perr.Value != 2048 || perr.Min != 4096 {
+		t.Logf("Expected master N ParamError, got %v", err)
+		t.FailNow()
+	}
+	if !errors.Is(err, ErrScryptParamN) {
+		t.Log("Expected ParamError to match ErrScryptParamN")
+		t.FailNow()
+	}
+	_, err = HashV2("password1234", "masterpassphrase", 0, Argon2Params{Time: 1, Memory: 8 * 1024, Threads: 1, KeyLen: 8}, DefaultParams)
+	if !errors.As(err, &perr) || perr.Layer != LayerUser || perr.Param != "KeyLen" || !errors.Is(err, ErrArgon2ParamKeyLen) {
+		t.Logf("Expected user KeyLen ParamError, got %v", err)
+		t.FailNow()
+	}
+
+	// Parameters stored in a hash are reported as the format error of their field
+	_, _, err = GetParams(strings.Replace(testV1Hash, "$32768$16$1$", "$32768$2$1$", 1))
+	var ferr *FormatError
+	if !errors.As(err, &ferr) || ferr.Field != "user params" || !errors.As(err, &perr) || perr.Layer != LayerUser || !errors.Is(err, ErrScryptParamR) {
+		t.Logf("Expected user R error, got %v", err)
+		t.FailNow()
+	}
+}
+
+func TestFormatErrorDetail(t *testing.T) {
+	parts := strings.Split(testV1Hash, "$")
+	tests := []struct {
+		index int
+		value string
+		field string
+	}{
+		{2, "!!!", "ciphertext"},
+		{2, "AAAA", "ciphertext"},
+		{3, "!!!", "master salt"},
+		{7, "x", "master N"},
+	}
+	for _, test := range tests {
+		bad := append([]string(nil), parts...)
+		bad[test.index] = test.value
+		ciphertext := strings.Join(bad, "$")
+		for _, err := range []error{
+			Verify("password1234", "masterpassphrase", ciphertext),
+			func() error {
+				_, err := UpdateMaster("masterpassphrase1", "masterpassphrase", 1, ciphertext, DefaultParams)
+				return err
+			}(),
+		} {
+			var ferr *FormatError
+			if !errors.As(err, &ferr) || ferr.Field != test.field || ferr.Index != test.index || !errors.Is(err, ErrCiphertextFormat) {
+				t.Logf("Expected %s format error, got %v", test.field, err)
+				t.FailNow()
+			}
+			if strings.Contains(err.Error(), "password1234") || strings.Contains(err.Error(), "masterpassphrase") {
+				t.Log("Error must not contain passphrases")
+				t.FailNow()
+			}
+		}
+	}
+}
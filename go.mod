@@ -1,9 +1,18 @@
 module github.com/dwin/goSecretBoxPassword
 
+go 1.21
+
 require (
-	github.com/corpix/uarand v0.0.0 // indirect
 	github.com/icrowley/fake v0.0.0-20180203215853-4178557ae428
-	github.com/stretchr/testify v1.3.0 // indirect
 	golang.org/x/crypto v0.0.0-20181203042331-505ab145d0a9
+	golang.org/x/text v0.21.0
+)
+
+require (
+	github.com/corpix/uarand v0.0.0 // indirect
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/stretchr/objx v0.1.0 // indirect
+	github.com/stretchr/testify v1.3.0 // indirect
 	golang.org/x/sys v0.0.0-20181213200352-4d1cda033e06 // indirect
 )
@@ -0,0 +1,246 @@
+package password
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"sync"
+)
+
+// maxCachedKeys limits the number of per-salt master keys a Hasher keeps for verifying hashes it did not create
+const maxCachedKeys = 1024
+
+// Hasher holds the secretbox key derived from a master passphrase so that the master passphrase Scrypt hash is
+// computed once rather than on every Hash and Verify call. Hashes created by a Hasher share a single master salt,
+// generated by NewHasher, and are in the secBoxv2 or secBoxv3 format so they can still be verified by the package level Verify.
+// For hashes created elsewhere, where the master salt is per hash, derived keys are cached by salt.
+// A Hasher is safe for concurrent use.
+type Hasher struct {
+	masterpass   string
+	version      int
+	masterParams ScryptParams
+	salt         []byte
+	key          [32]byte
+	rand         io.Reader
+
+	mu     sync.Mutex
+	keys   map[string]*[32]byte
+	cipher string
+	policy *Policy
+}
+
+// NewHasher takes masterpassphrase as string, version indicator as int and masterparams as ScryptParams and returns
+// a Hasher and error - ex. password.NewHasher("masterpassphrase", 0, DefaultParams)
+func NewHasher(masterpass string, version int, masterparams ScryptParams) (*Hasher, error) {
+	return NewHasherRand(masterpass, version, masterparams, rand.Reader)
+}
+
+// NewHasherRand is NewHasher with salts and nonces read from random rather than crypto/rand, ex. a DRBG seeded from
+// an HSM. random must be safe for concurrent use if the Hasher is. Read failures are returned as *RandError.
+func NewHasherRand(masterpass string, version int, masterparams ScryptParams, random io.Reader) (*Hasher, error) {
+	if len(masterpass) < MinLength {
+		return nil, ErrPassphraseLength
+	}
+	err := validateMaster(masterparams)
+	if err != nil {
+		return nil, err
+	}
+	// Generate random salt for master passphrase Scrypt hash
+	key, salt, err := newMasterKey(context.Background(), random, masterpass, masterparams)
+	if err != nil {
+		return nil, err
+	}
+	return &Hasher{
+		masterpass:   masterpass,
+		version:      version,
+		masterParams: masterparams,
+		salt:         salt,
+		key:          key,
+		rand:         random,
+		keys:         make(map[string]*[32]byte),
+		cipher:       CipherSecretbox,
+	}, nil
+}
+
+// SetCipher sets the master layer cipher used for new hashes, ex. CipherAES256GCM. Hashes using any cipher are
+// verified regardless of this setting.
+func (h *Hasher) SetCipher(cipher string) error {
+	if err := validateCipher(cipher); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	h.cipher = cipher
+	h.mu.Unlock()
+	return nil
+}
+
+// Cipher returns the master layer cipher used for new hashes
+func (h *Hasher) Cipher() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.cipher
+}
+
+// SetPolicy sets the Policy user passphrases must meet for Hash, in place of MinLength. Identity rules are not
+// checked as Hash is not given the user's identities, call Policy.Check with them before hashing. A nil Policy
+// restores the MinLength check.
+func (h *Hasher) SetPolicy(p *Policy) {
+	p = p.clone()
+	h.mu.Lock()
+	h.policy = p
+	h.mu.Unlock()
+}
+
+// checkPolicy checks userpass against the Hasher's Policy, or MinLength if none is set
+func (h *Hasher) checkPolicy(ctx context.Context, userpass string) error {
+	h.mu.Lock()
+	p := h.policy
+	h.mu.Unlock()
+	if p == nil {
+		if len(userpass) < MinLength {
+			return ErrPassphraseLength
+		}
+		return nil
+	}
+	return p.CheckContext(ctx, userpass)
+}
+
+// Version returns the master passphrase version the Hasher stamps on new hashes
+func (h *Hasher) Version() int {
+	return h.version
+}
+
+// Hash takes passphrase as string and userparams as ScryptParams or Argon2Params and returns ciphertext string and error.
+// The output is in secBoxv2 format, or secBoxv3 if a cipher other than secretbox was set with SetCipher.
+func (h *Hasher) Hash(userpass string, userparams UserParams) (pwHashOut string, err error) {
+	return h.HashContext(context.Background(), userpass, userparams)
+}
+
+// HashContext is Hash returning ctx.Err() as soon as ctx is cancelled or its deadline passes
+func (h *Hasher) HashContext(ctx context.Context, userpass string, userparams UserParams) (pwHashOut string, err error) {
+	if err = h.checkPolicy(ctx, userpass); err != nil {
+		return "", err
+	}
+	return h.hash(ctx, userpass, userparams)
+}
+
+// hash is HashContext without the passphrase policy check
+func (h *Hasher) hash(ctx context.Context, userpass string, userparams UserParams) (pwHashOut string, err error) {
+	err = validateUser(userparams)
+	if err != nil {
+		return
+	}
+	var userpassHash []byte
+	err = contextDo(ctx, func() (err error) {
+		userpassHash, err = userparams.hash(ctx, h.rand, userpass)
+		return
+	})
+	if err != nil {
+		return
+	}
+	cipher := h.Cipher()
+	out := &ParsedHash{
+		Version:       formatVersion(cipher),
+		MasterVersion: h.version,
+		Cipher:        cipher,
+		MasterSalt:    h.salt,
+		UserParams:    userparams,
+		MasterParams:  h.masterParams,
+	}
+	if err = out.seal(h.rand, &h.key, userpassHash); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// Verify takes passphrase and ciphertext as strings and returns error if verification fails, else returns nil upon success
+func (h *Hasher) Verify(userpass, ciphertext string) error {
+	return h.VerifyContext(context.Background(), userpass, ciphertext)
+}
+
+// VerifyContext is Verify returning ctx.Err() as soon as ctx is cancelled or its deadline passes
+func (h *Hasher) VerifyContext(ctx context.Context, userpass, ciphertext string) error {
+	parsed, err := ParseHash(ciphertext)
+	if err != nil {
+		return err
+	}
+	return h.verify(ctx, userpass, parsed)
+}
+
+func (h *Hasher) verify(ctx context.Context, userpass string, parsed *ParsedHash) error {
+	var decrypted []byte
+	err := contextDo(ctx, func() (err error) {
+		decrypted, err = h.open(ctx, parsed)
+		return
+	})
+	if err != nil {
+		return err
+	}
+	return contextDo(ctx, func() error {
+		return parsed.UserParams.verify(ctx, userpass, decrypted)
+	})
+}
+
+// Rotate takes the Hasher for the master passphrase the ciphertext was created with and ciphertext as string, and
+// returns the hash re-encrypted under this Hasher's master passphrase and version. The user passphrase hash and
+// format version are unchanged.
+func (h *Hasher) Rotate(old *Hasher, ciphertext string) (pwHashOut string, err error) {
+	parsed, err := ParseHash(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return h.rotate(old, parsed)
+}
+
+func (h *Hasher) rotate(old *Hasher, parsed *ParsedHash) (pwHashOut string, err error) {
+	if h.version <= parsed.MasterVersion {
+		return "", ErrInvalidVersionUpdate
+	}
+	decrypted, err := old.open(context.Background(), parsed)
+	if err != nil {
+		return "", err
+	}
+	rotated := *parsed
+	rotated.MasterVersion = h.version
+	rotated.MasterParams = h.masterParams
+	rotated.MasterSalt = h.salt
+	if err = rotated.seal(h.rand, &h.key, decrypted); err != nil {
+		return "", err
+	}
+	return rotated.String(), nil
+}
+
+// open decrypts the hash using the Hasher's key if the hash shares its master salt, otherwise a cached or newly derived key
+func (h *Hasher) open(ctx context.Context, parsed *ParsedHash) ([]byte, error) {
+	key, err := h.masterKey(ctx, parsed.MasterSalt, parsed.MasterParams)
+	if err != nil {
+		return nil, err
+	}
+	return parsed.open(key)
+}
+
+func (h *Hasher) masterKey(ctx context.Context, salt []byte, masterparams ScryptParams) (*[32]byte, error) {
+	if masterparams == h.masterParams && bytes.Equal(salt, h.salt) {
+		return &h.key, nil
+	}
+	id := masterparams.encode() + "$" + string(salt)
+	h.mu.Lock()
+	key, ok := h.keys[id]
+	h.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	derived, err := masterKey(ctx, h.masterpass, salt, masterparams)
+	if err != nil {
+		return nil, err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// Start over rather than grow without bound when many distinct salts are seen
+	if len(h.keys) >= maxCachedKeys {
+		h.keys = make(map[string]*[32]byte)
+	}
+	h.keys[id] = &derived
+	return &derived, nil
+}
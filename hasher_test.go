@@ -0,0 +1,260 @@
+package password
+
+import (
+	"errors"
+	"io"
+	mrand "math/rand"
+	"sync"
+	"testing"
+)
+
+func TestHasher(t *testing.T) {
+	h, err := NewHasher("masterpassphrase", 0, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	for _, userparams := range []UserParams{testArgon2Params, DefaultParams} {
+		output, err := h.Hash("password1234", userparams)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		// Hashes created by Hasher are verifiable by both Hasher and package level Verify
+		if err := h.Verify("password1234", output); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if err := Verify("password1234", "masterpassphrase", output); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if err := h.Verify("passw0rd1234", output); err != ErrPassphraseHashMismatch {
+			t.Log("Expected passphrase mismatch")
+			t.FailNow()
+		}
+	}
+
+	// Hashes created by package level functions use per hash salts
+	for _, hashFn := range []func() (string, error){
+		func() (string, error) {
+			return Hash("password1234", "masterpassphrase", 0, DefaultParams, DefaultParams)
+		},
+		func() (string, error) {
+			return HashV2("password1234", "masterpassphrase", 0, testArgon2Params, DefaultParams)
+		},
+	} {
+		output, err := hashFn()
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if err := h.Verify("password1234", output); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		// Second verify uses cached key
+		if err := h.Verify("password1234", output); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+	}
+	if len(h.keys) != 2 {
+		t.Logf("Expected 2 cached keys, got %v", len(h.keys))
+		t.FailNow()
+	}
+
+	// Wrong master passphrase
+	other, err := NewHasher("mast3rpassphrase", 0, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	output, err := h.Hash("password1234", DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err := other.Verify("password1234", output); err != ErrSecretBoxDecryptFail {
+		t.Log("Expected decryption failure")
+		t.FailNow()
+	}
+}
+
+func TestNewHasherBadInput(t *testing.T) {
+	_, err := NewHasher("master", 0, DefaultParams)
+	if err != ErrPassphraseLength {
+		t.Log("Expected Passphrase length failure")
+		t.FailNow()
+	}
+	_, err = NewHasher("masterpassphrase", 0, ScryptParams{N: 2048, R: 8, P: 1})
+	if !errors.Is(err, ErrScryptParamN) {
+		t.Log("Expected Scrypt N failure for master params")
+		t.FailNow()
+	}
+	h, err := NewHasher("masterpassphrase", 0, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if _, err := h.Hash("pass", DefaultParams); err != ErrPassphraseLength {
+		t.Log("Expected Passphrase length failure")
+		t.FailNow()
+	}
+	if _, err := h.Hash("password1234", nil); !errors.Is(err, ErrUnsupportedKDF) {
+		t.Log("Expected unsupported KDF failure")
+		t.FailNow()
+	}
+	if err := h.Verify("password1234", "secBoxv1$0$bad"); !errors.Is(err, ErrCiphertextFormat) {
+		t.Log("Expected Ciphertext format failure")
+		t.FailNow()
+	}
+}
+
+func TestHasherRotate(t *testing.T) {
+	oldH, err := NewHasher("masterpassphrase", 0, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	newH, err := NewHasher("masterpassphrase2", 1, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	v1, err := Hash("password1234", "masterpassphrase", 0, DefaultParams, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	v2, err := oldH.Hash("password1234", testArgon2Params)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	for _, output := range []string{v1, v2} {
+		hv, _ := GetHashVersion(output)
+		rotated, err := newH.Rotate(oldH, output)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if v, _ := GetHashVersion(rotated); v != hv {
+			t.Log("Expected format version to be unchanged")
+			t.FailNow()
+		}
+		if v, _ := GetMasterVersion(rotated); v != 1 {
+			t.Log("Expected master version 1")
+			t.FailNow()
+		}
+		if err := Verify("password1234", "masterpassphrase2", rotated); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		// Rotating again to the same version must fail
+		if _, err := newH.Rotate(oldH, rotated); err != ErrInvalidVersionUpdate {
+			t.Log("Expected Invalid Version update error")
+			t.FailNow()
+		}
+	}
+	// Wrong old master passphrase
+	if _, err := newH.Rotate(newH, v2); err != ErrSecretBoxDecryptFail {
+		t.Log("Expected decryption failure")
+		t.FailNow()
+	}
+}
+
+func TestHasherConcurrent(t *testing.T) {
+	h, err := NewHasher("masterpassphrase", 0, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	output, err := HashV2("password1234", "masterpassphrase", 0, testArgon2Params, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- h.Verify("password1234", output)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+	}
+}
+
+// failingReader returns n bytes of zeros and then fails
+type failingReader struct {
+	n int
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.n <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if len(p) > r.n {
+		p = p[:r.n]
+	}
+	for i := range p {
+		p[i] = 0
+	}
+	r.n -= len(p)
+	return len(p), nil
+}
+
+func TestNewHasherRand(t *testing.T) {
+	// Identical random sources give known answers
+	var outputs []string
+	for i := 0; i < 2; i++ {
+		h, err := NewHasherRand("masterpassphrase", 0, DefaultParams, mrand.New(mrand.NewSource(1)))
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		output, err := h.Hash("password1234", testArgon2Params)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if err = Verify("password1234", "masterpassphrase", output); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		outputs = append(outputs, output)
+	}
+	if outputs[0] != outputs[1] {
+		t.Log("Expected identical output for identical random sources")
+		t.FailNow()
+	}
+
+	var rerr *RandError
+	if _, err := NewHasherRand("masterpassphrase", 0, DefaultParams, &failingReader{}); !errors.As(err, &rerr) || rerr.Err != io.ErrUnexpectedEOF {
+		t.Logf("Expected RandError, got %v", err)
+		t.FailNow()
+	}
+	// Enough for the master salt only
+	h, err := NewHasherRand("masterpassphrase", 0, DefaultParams, &failingReader{n: 8})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if _, err = h.Hash("password1234", testArgon2Params); !errors.As(err, &rerr) {
+		t.Logf("Expected RandError, got %v", err)
+		t.FailNow()
+	}
+	if err = h.VerifyAbsent("password1234", testArgon2Params); !errors.As(err, &rerr) {
+		t.Logf("Expected RandError, got %v", err)
+		t.FailNow()
+	}
+}
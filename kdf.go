@@ -0,0 +1,291 @@
+package password
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// KDFScrypt identifies the Scrypt user passphrase KDF in secBoxv2 hashes
+	KDFScrypt = "scrypt"
+	// KDFArgon2id identifies the Argon2id user passphrase KDF in secBoxv2 hashes
+	KDFArgon2id = "argon2id"
+)
+
+// DefaultArgon2Params defines Argon2id Parameters, per the second recommended option of RFC 9106
+var DefaultArgon2Params = Argon2Params{Time: 3, Memory: 64 * 1024, Threads: 4, KeyLen: 32}
+
+// UserParams is implemented by the parameter types of each supported user passphrase KDF, currently ScryptParams,
+// Argon2Params, PBKDF2Params and BcryptParams, optionally wrapped in NormalizedParams.
+// The KDF and its parameters are encoded in secBoxv2 hashes.
+type UserParams interface {
+	// KDF returns the KDF identifier stored in the hash, ex. "argon2id"
+	KDF() string
+	// encode returns the parameters as a comma separated list of key=value pairs
+	encode() string
+	validate() error
+	// size returns the length in bytes of the output of hash
+	size() int
+	// hash derives the output to be encrypted by the master layer from the user passphrase, with a salt read from
+	// random or crypto/rand if nil
+	hash(ctx context.Context, random io.Reader, userpass string) ([]byte, error)
+	// verify compares the user passphrase against the decrypted output of hash
+	verify(ctx context.Context, userpass string, decrypted []byte) error
+}
+
+// Argon2Params sets the Argon2id derivation parameters used for hashing, Memory is given in KiB
+type Argon2Params struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+	KeyLen  uint32
+}
+
+// KDF returns "scrypt"
+func (p ScryptParams) KDF() string { return KDFScrypt }
+
+func (p ScryptParams) encode() string {
+	return fmt.Sprintf("n=%v,r=%v,p=%v", p.N, p.R, p.P)
+}
+
+func (p ScryptParams) validate() error { return validateParams(p) }
+
+func (p ScryptParams) size() int { return 64 }
+
+func (p ScryptParams) hash(ctx context.Context, random io.Reader, userpass string) ([]byte, error) {
+	// The plaintext password is transformed into a hash value using Blake2b-512, then hashed again using Scrypt
+	// plus random 8 byte salt, generating 56 byte output with salt appended for 64 byte total output
+	userPwBlake := blake2b.Sum512([]byte(userpass))
+	salt, err := randBytes(random, 8)
+	if err != nil {
+		return nil, err
+	}
+	return scryptHash(ctx, hex.EncodeToString(userPwBlake[:]), salt, p)
+}
+
+func (p ScryptParams) verify(ctx context.Context, userpass string, decrypted []byte) error {
+	if len(decrypted) != 64 {
+		return ErrCiphertextFormat
+	}
+	userPwBlake := blake2b.Sum512([]byte(userpass))
+	userpassScrypt, err := scryptHash(ctx, hex.EncodeToString(userPwBlake[:]), decrypted[56:], p)
+	if err != nil {
+		return err
+	}
+	if res := subtle.ConstantTimeCompare(decrypted, userpassScrypt); res != 1 {
+		return ErrPassphraseHashMismatch
+	}
+	return nil
+}
+
+// KDF returns "argon2id"
+func (p Argon2Params) KDF() string { return KDFArgon2id }
+
+func (p Argon2Params) encode() string {
+	return fmt.Sprintf("m=%v,t=%v,p=%v,l=%v", p.Memory, p.Time, p.Threads, p.KeyLen)
+}
+
+func (p Argon2Params) size() int { return int(p.KeyLen) + 16 }
+
+func (p Argon2Params) validate() error {
+	if p.Time < 1 || p.Time > 100 {
+		return &ParamError{Param: "Time", Value: int64(p.Time), Min: 1, Max: 100, Err: ErrArgon2ParamTime}
+	}
+	// Argon2 requires at least 8KiB per thread, we require a more sensible 8MiB up to 4GiB
+	if p.Memory < 8*1024 || p.Memory > 4*1024*1024 {
+		return &ParamError{Param: "Memory", Value: int64(p.Memory), Min: 8 * 1024, Max: 4 * 1024 * 1024, Err: ErrArgon2ParamMemory}
+	}
+	if p.Threads < 1 {
+		return &ParamError{Param: "Threads", Value: int64(p.Threads), Min: 1, Max: 255, Err: ErrArgon2ParamThreads}
+	}
+	if p.KeyLen < 16 || p.KeyLen > 64 {
+		return &ParamError{Param: "KeyLen", Value: int64(p.KeyLen), Min: 16, Max: 64, Err: ErrArgon2ParamKeyLen}
+	}
+	return nil
+}
+
+// validateUser validates user passphrase KDF parameters, errors are *ParamError with Layer LayerUser
+func validateUser(p UserParams) error {
+	if p == nil {
+		return ErrUnsupportedKDF
+	}
+	return withLayer(p.validate(), LayerUser)
+}
+
+// validateMaster validates master passphrase Scrypt parameters, errors are *ParamError with Layer LayerMaster
+func validateMaster(p ScryptParams) error {
+	return withLayer(validateParams(p), LayerMaster)
+}
+
+func (p Argon2Params) hash(ctx context.Context, random io.Reader, userpass string) ([]byte, error) {
+	salt, err := randBytes(random, 16)
+	if err != nil {
+		return nil, err
+	}
+	return argon2Hash(ctx, userpass, salt, p)
+}
+
+func (p Argon2Params) verify(ctx context.Context, userpass string, decrypted []byte) error {
+	if len(decrypted) != int(p.KeyLen)+16 {
+		return ErrCiphertextFormat
+	}
+	userpassArgon2, err := argon2Hash(ctx, userpass, decrypted[p.KeyLen:], p)
+	if err != nil {
+		return err
+	}
+	if res := subtle.ConstantTimeCompare(decrypted, userpassArgon2); res != 1 {
+		return ErrPassphraseHashMismatch
+	}
+	return nil
+}
+
+func argon2Hash(ctx context.Context, userpass string, salt []byte, params Argon2Params) (hash []byte, err error) {
+	err = params.validate()
+	if err != nil {
+		return nil, err
+	}
+	release, err := admit(ctx, uint64(params.Memory)*1024)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	// 1) The plaintext password is transformed into a hash value using Blake2b-512
+	userPwBlake := blake2b.Sum512([]byte(userpass))
+	// 2) Blake2b hash is hashed again using Argon2id with supplied 16 byte salt, with salt appended to output
+	key := argon2.IDKey([]byte(hex.EncodeToString(userPwBlake[:])), salt, params.Time, params.Memory, params.Threads, params.KeyLen)
+	output := make([]byte, len(key)+len(salt))
+	copy(output, key)
+	copy(output[len(key):], salt)
+	return output, nil
+}
+
+// ParseUserParams takes KDF identifier and parameters as encoded in secBoxv2 hashes, ex. "scrypt" and "n=32768,r=16,p=1", and returns validated UserParams and error
+func ParseUserParams(kdf, encoded string) (UserParams, error) {
+	values, err := parseParamList(encoded)
+	if err != nil {
+		return nil, err
+	}
+	form, ok := values["norm"]
+	if !ok {
+		return parseUserValues(kdf, values)
+	}
+	delete(values, "norm")
+	inner, err := parseUserValues(kdf, values)
+	if err != nil {
+		return nil, err
+	}
+	p := NormalizedParams{Params: inner, Form: form}
+	return p, p.validate()
+}
+
+// parseUserValues returns validated UserParams of kdf from its parameter values
+func parseUserValues(kdf string, values map[string]string) (UserParams, error) {
+	var err error
+	switch kdf {
+	case KDFScrypt:
+		var p ScryptParams
+		if p.N, err = paramInt(values, "n"); err != nil {
+			return nil, err
+		}
+		if p.R, err = paramInt(values, "r"); err != nil {
+			return nil, err
+		}
+		if p.P, err = paramInt(values, "p"); err != nil {
+			return nil, err
+		}
+		if len(values) != 3 {
+			return nil, ErrCiphertextFormat
+		}
+		return p, p.validate()
+	case KDFArgon2id:
+		var p Argon2Params
+		if p.Memory, err = paramUint32(values, "m"); err != nil {
+			return nil, err
+		}
+		if p.Time, err = paramUint32(values, "t"); err != nil {
+			return nil, err
+		}
+		threads, err := paramUint32(values, "p")
+		if err != nil || threads > 255 {
+			return nil, ErrCiphertextFormat
+		}
+		p.Threads = uint8(threads)
+		if p.KeyLen, err = paramUint32(values, "l"); err != nil {
+			return nil, err
+		}
+		if len(values) != 4 {
+			return nil, ErrCiphertextFormat
+		}
+		return p, p.validate()
+	case KDFPBKDF2SHA256, KDFPBKDF2SHA512:
+		p := PBKDF2Params{Hash: kdf[len("pbkdf2-"):]}
+		if p.Iterations, err = paramInt(values, "i"); err != nil {
+			return nil, err
+		}
+		if p.KeyLen, err = paramInt(values, "l"); err != nil {
+			return nil, err
+		}
+		if len(values) != 2 {
+			return nil, ErrCiphertextFormat
+		}
+		return p, p.validate()
+	case KDFBcrypt:
+		var p BcryptParams
+		if p.Cost, err = paramInt(values, "c"); err != nil {
+			return nil, err
+		}
+		if len(values) != 1 {
+			return nil, ErrCiphertextFormat
+		}
+		return p, p.validate()
+	}
+	return nil, ErrUnsupportedKDF
+}
+
+// parseParamList splits "a=1,b=2" into a map of keys to values
+func parseParamList(s string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, kv := range strings.Split(s, ",") {
+		i := strings.IndexByte(kv, '=')
+		if i < 1 {
+			return nil, ErrCiphertextFormat
+		}
+		if _, ok := values[kv[:i]]; ok {
+			return nil, ErrCiphertextFormat
+		}
+		values[kv[:i]] = kv[i+1:]
+	}
+	return values, nil
+}
+
+func paramInt(values map[string]string, key string) (int, error) {
+	s, ok := values[key]
+	if !ok {
+		return 0, ErrCiphertextFormat
+	}
+	// Reject signs and leading zeros so that each value has only one valid encoding
+	if s == "" || s[0] == '+' || s[0] == '-' || (len(s) > 1 && s[0] == '0') {
+		return 0, ErrCiphertextFormat
+	}
+	return strconv.Atoi(s)
+}
+
+func paramUint32(values map[string]string, key string) (uint32, error) {
+	v, err := paramInt(values, key)
+	if err != nil {
+		return 0, err
+	}
+	if v > math.MaxUint32 {
+		return 0, ErrCiphertextFormat
+	}
+	return uint32(v), nil
+}
@@ -0,0 +1,104 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// testArgon2Params keeps Argon2id test runs fast while remaining within validation limits
+var testArgon2Params = Argon2Params{Time: 1, Memory: 8 * 1024, Threads: 2, KeyLen: 32}
+
+func TestArgon2ValidateParams(t *testing.T) {
+	// This should pass
+	err := DefaultArgon2Params.validate()
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	// These should fail
+	if err := (Argon2Params{Time: 0, Memory: 65536, Threads: 4, KeyLen: 32}).validate(); !errors.Is(err, ErrArgon2ParamTime) {
+		t.Log("Expected Argon2 time param failure")
+		t.FailNow()
+	}
+	if err := (Argon2Params{Time: 3, Memory: 1024, Threads: 4, KeyLen: 32}).validate(); !errors.Is(err, ErrArgon2ParamMemory) {
+		t.Log("Expected Argon2 memory param failure")
+		t.FailNow()
+	}
+	if err := (Argon2Params{Time: 3, Memory: 65536, Threads: 0, KeyLen: 32}).validate(); !errors.Is(err, ErrArgon2ParamThreads) {
+		t.Log("Expected Argon2 threads param failure")
+		t.FailNow()
+	}
+	if err := (Argon2Params{Time: 3, Memory: 65536, Threads: 4, KeyLen: 8}).validate(); !errors.Is(err, ErrArgon2ParamKeyLen) {
+		t.Log("Expected Argon2 key length param failure")
+		t.FailNow()
+	}
+}
+
+func TestParseUserParams(t *testing.T) {
+	p, err := ParseUserParams(KDFArgon2id, testArgon2Params.encode())
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if p != testArgon2Params {
+		t.Log("Returned parameters do not match given")
+		t.FailNow()
+	}
+	p, err = ParseUserParams(KDFScrypt, "n=32768,r=16,p=1")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if p != (ScryptParams{N: 32768, R: 16, P: 1}) {
+		t.Log("Returned parameters do not match given")
+		t.FailNow()
+	}
+
+	// These should fail
+	bad := []struct{ kdf, encoded string }{
+		{"bcrypt", "cost=10"},
+		{KDFScrypt, "n=32768,r=16"},
+		{KDFScrypt, "n=32768,r=16,p=1,x=1"},
+		{KDFScrypt, "n=32768,r=16,p=1,p=1"},
+		{KDFScrypt, "n=032768,r=16,p=1"},
+		{KDFScrypt, "n=+32768,r=16,p=1"},
+		{KDFScrypt, "n=2048,r=16,p=1"},
+		{KDFArgon2id, "m=65536,t=3,p=4"},
+		{KDFArgon2id, "m=65536,t=3,p=256,l=32"},
+		{KDFArgon2id, "m=4294975488,t=3,p=4,l=32"},
+		{KDFArgon2id, "m=65536,t=x,p=4,l=32"},
+		{KDFArgon2id, ""},
+	}
+	for _, b := range bad {
+		if _, err := ParseUserParams(b.kdf, b.encoded); err == nil {
+			t.Logf("Expected parse failure for %s %s", b.kdf, b.encoded)
+			t.FailNow()
+		}
+	}
+}
+
+func TestArgon2Hash(t *testing.T) {
+	out, err := testArgon2Params.hash(context.Background(), nil, "password1234")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if len(out) != int(testArgon2Params.KeyLen)+16 {
+		t.Log("Unexpected Argon2 output length")
+		t.FailNow()
+	}
+	if err := testArgon2Params.verify(context.Background(), "password1234", out); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err := testArgon2Params.verify(context.Background(), "passw0rd1234", out); err != ErrPassphraseHashMismatch {
+		t.Log("Expected passphrase mismatch")
+		t.FailNow()
+	}
+	if err := testArgon2Params.verify(context.Background(), "password1234", out[1:]); err != ErrCiphertextFormat {
+		t.Log("Expected format failure for truncated output")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,180 @@
+package password
+
+import (
+	"context"
+	"sync"
+)
+
+// Keyring maps master passphrase versions to Hashers with one designated current version. Hash always stamps the
+// current version while Verify selects the master passphrase matching the version stored in each ciphertext, so
+// hashes created under several master passphrases can be verified during a rotation.
+// A Keyring is safe for concurrent use.
+type Keyring struct {
+	mu      sync.RWMutex
+	hashers map[int]*Hasher
+	current int
+	policy  *Policy
+}
+
+// NewKeyring takes current version as int, masterparams as ScryptParams and master passphrases by version, and returns
+// a Keyring and error - ex. password.NewKeyring(1, DefaultParams, map[int]string{0: "masterpassphrase", 1: "masterpassphrase2"})
+func NewKeyring(current int, masterparams ScryptParams, masters map[int]string) (*Keyring, error) {
+	k := &Keyring{hashers: make(map[int]*Hasher)}
+	for version, masterpass := range masters {
+		if err := k.Add(version, masterpass, masterparams); err != nil {
+			return nil, err
+		}
+	}
+	if err := k.SetCurrent(current); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Add takes version as int, masterpassphrase as string and masterparams as ScryptParams and adds a Hasher for it to the Keyring.
+// The current version is not changed.
+func (k *Keyring) Add(version int, masterpass string, masterparams ScryptParams) error {
+	h, err := NewHasher(masterpass, version, masterparams)
+	if err != nil {
+		return err
+	}
+	return k.AddHasher(h)
+}
+
+// AddHasher adds an existing Hasher to the Keyring under its version. The current version is not changed.
+func (k *Keyring) AddHasher(h *Hasher) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.hashers == nil {
+		k.hashers = make(map[int]*Hasher)
+	}
+	if _, ok := k.hashers[h.version]; ok {
+		return ErrDuplicateMasterVersion
+	}
+	k.hashers[h.version] = h
+	return nil
+}
+
+// Remove takes version as int and removes its master passphrase from the Keyring, the current version can not be removed.
+func (k *Keyring) Remove(version int) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.hashers[version]; !ok || version == k.current {
+		return ErrUnknownMasterVersion
+	}
+	delete(k.hashers, version)
+	return nil
+}
+
+// SetCurrent takes version as int and designates it as the version used for new hashes
+func (k *Keyring) SetCurrent(version int) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.hashers[version]; !ok {
+		return ErrUnknownMasterVersion
+	}
+	k.current = version
+	return nil
+}
+
+// SetPolicy sets the Policy user passphrases must meet for Hash, in place of the Policy or MinLength of the current
+// Hasher. See Hasher.SetPolicy.
+func (k *Keyring) SetPolicy(p *Policy) {
+	p = p.clone()
+	k.mu.Lock()
+	k.policy = p
+	k.mu.Unlock()
+}
+
+// Current returns the master passphrase version used for new hashes
+func (k *Keyring) Current() int {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current
+}
+
+// Versions returns the master passphrase versions held by the Keyring in no particular order
+func (k *Keyring) Versions() []int {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	versions := make([]int, 0, len(k.hashers))
+	for v := range k.hashers {
+		versions = append(versions, v)
+	}
+	return versions
+}
+
+// Hasher takes version as int and returns the Hasher for that master passphrase version
+func (k *Keyring) Hasher(version int) (*Hasher, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	h, ok := k.hashers[version]
+	if !ok {
+		return nil, ErrUnknownMasterVersion
+	}
+	return h, nil
+}
+
+// Hash takes passphrase as string and userparams as ScryptParams or Argon2Params and returns secBoxv2 ciphertext
+// string using the current master passphrase version and error
+func (k *Keyring) Hash(userpass string, userparams UserParams) (string, error) {
+	return k.HashContext(context.Background(), userpass, userparams)
+}
+
+// HashContext is Hash returning ctx.Err() as soon as ctx is cancelled or its deadline passes
+func (k *Keyring) HashContext(ctx context.Context, userpass string, userparams UserParams) (string, error) {
+	h, err := k.Hasher(k.Current())
+	if err != nil {
+		return "", err
+	}
+	k.mu.RLock()
+	p := k.policy
+	k.mu.RUnlock()
+	if p == nil {
+		return h.HashContext(ctx, userpass, userparams)
+	}
+	if err = p.CheckContext(ctx, userpass); err != nil {
+		return "", err
+	}
+	return h.hash(ctx, userpass, userparams)
+}
+
+// Verify takes passphrase and ciphertext as strings and returns error if verification fails, else returns nil upon success.
+// The master passphrase is selected by the version stored in ciphertext.
+func (k *Keyring) Verify(userpass, ciphertext string) error {
+	return k.VerifyContext(context.Background(), userpass, ciphertext)
+}
+
+// VerifyContext is Verify returning ctx.Err() as soon as ctx is cancelled or its deadline passes
+func (k *Keyring) VerifyContext(ctx context.Context, userpass, ciphertext string) error {
+	parsed, err := ParseHash(ciphertext)
+	if err != nil {
+		return err
+	}
+	h, err := k.Hasher(parsed.MasterVersion)
+	if err != nil {
+		return err
+	}
+	return h.verify(ctx, userpass, parsed)
+}
+
+// Rotate takes ciphertext as string and returns it re-encrypted under the current master passphrase version and error
+func (k *Keyring) Rotate(ciphertext string) (string, error) {
+	parsed, err := ParseHash(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return k.rotate(parsed)
+}
+
+func (k *Keyring) rotate(parsed *ParsedHash) (string, error) {
+	old, err := k.Hasher(parsed.MasterVersion)
+	if err != nil {
+		return "", err
+	}
+	h, err := k.Hasher(k.Current())
+	if err != nil {
+		return "", err
+	}
+	return h.rotate(old, parsed)
+}
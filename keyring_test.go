@@ -0,0 +1,115 @@
+package password
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestKeyring(t *testing.T) {
+	masters := map[int]string{0: "masterpassphrase", 1: "masterpassphrase1", 2: "masterpassphrase2"}
+	k, err := NewKeyring(2, DefaultParams, masters)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if k.Current() != 2 || len(k.Versions()) != 3 {
+		t.Log("Unexpected keyring state")
+		t.FailNow()
+	}
+
+	// Hashes under every version verify without selecting the master passphrase by hand
+	for version, masterpass := range masters {
+		output, err := HashV2("password1234", masterpass, version, testArgon2Params, DefaultParams)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if err := k.Verify("password1234", output); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if err := k.Verify("passw0rd1234", output); err != ErrPassphraseHashMismatch {
+			t.Log("Expected passphrase mismatch")
+			t.FailNow()
+		}
+		if version < 2 {
+			rotated, err := k.Rotate(output)
+			if err != nil {
+				t.Log(err)
+				t.FailNow()
+			}
+			if err := Verify("password1234", "masterpassphrase2", rotated); err != nil {
+				t.Log(err)
+				t.FailNow()
+			}
+		}
+	}
+
+	// Hash stamps current version
+	output, err := k.Hash("password1234", testArgon2Params)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if v, _ := GetMasterVersion(output); v != 2 {
+		t.Log("Expected current master version")
+		t.FailNow()
+	}
+	if err := Verify("password1234", "masterpassphrase2", output); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	// Unknown version
+	if err := k.Remove(0); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	output, err = Hash("password1234", "masterpassphrase", 0, DefaultParams, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err := k.Verify("password1234", output); err != ErrUnknownMasterVersion {
+		t.Log("Expected unknown master version")
+		t.FailNow()
+	}
+	if _, err := k.Rotate(output); err != ErrUnknownMasterVersion {
+		t.Log("Expected unknown master version")
+		t.FailNow()
+	}
+}
+
+func TestKeyringBadInput(t *testing.T) {
+	_, err := NewKeyring(1, DefaultParams, map[int]string{0: "masterpassphrase"})
+	if err != ErrUnknownMasterVersion {
+		t.Log("Expected unknown master version")
+		t.FailNow()
+	}
+	_, err = NewKeyring(0, DefaultParams, map[int]string{0: "master"})
+	if err != ErrPassphraseLength {
+		t.Log("Expected Passphrase length failure")
+		t.FailNow()
+	}
+	k, err := NewKeyring(0, DefaultParams, map[int]string{0: "masterpassphrase"})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err := k.Add(0, "masterpassphrase", DefaultParams); err != ErrDuplicateMasterVersion {
+		t.Log("Expected duplicate master version")
+		t.FailNow()
+	}
+	if err := k.Remove(0); err != ErrUnknownMasterVersion {
+		t.Log("Expected current version removal to fail")
+		t.FailNow()
+	}
+	if err := k.SetCurrent(3); err != ErrUnknownMasterVersion {
+		t.Log("Expected unknown master version")
+		t.FailNow()
+	}
+	if err := k.Verify("password1234", "secBoxv2$0"); !errors.Is(err, ErrCiphertextFormat) {
+		t.Log("Expected Ciphertext format failure")
+		t.FailNow()
+	}
+}
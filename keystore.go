@@ -0,0 +1,281 @@
+package password
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// keystoreFormat is the version of the Keystore JSON layout
+const keystoreFormat = 1
+
+// Keystore holds versioned master passphrases, each sealed with NaCl secretbox under a key encryption key derived
+// from an operator passphrase with Scrypt, the same construction as the master layer of a hash. Its JSON encoding
+// holds no plaintext so it can be stored on disk or in configuration. A Keystore is a KeyProvider and is safe for
+// concurrent use.
+type Keystore struct {
+	mu      sync.RWMutex
+	current int
+	params  ScryptParams
+	salt    []byte
+	kek     [32]byte
+	keys    map[int][]byte
+}
+
+// keystoreJSON is the stored form of a Keystore, byte slices are base64 encoded
+type keystoreJSON struct {
+	Format  int            `json:"format"`
+	Current int            `json:"current"`
+	N       int            `json:"n"`
+	R       int            `json:"r"`
+	P       int            `json:"p"`
+	Salt    []byte         `json:"salt"`
+	Check   []byte         `json:"check"`
+	Keys    map[int][]byte `json:"keys"`
+}
+
+// NewKeystore takes the operator passphrase as string and params as ScryptParams for deriving the key encryption
+// key and returns an empty Keystore and error - ex. password.NewKeystore("operatorpassphrase", DefaultParams)
+func NewKeystore(operatorpass string, params ScryptParams) (*Keystore, error) {
+	ks := &Keystore{keys: make(map[int][]byte)}
+	if err := ks.setPassphrase(operatorpass, params); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// OpenKeystore takes data as produced by Keystore.Encode and the operator passphrase as string and returns the
+// Keystore and error. ErrKeystorePassphrase is returned if the operator passphrase is wrong or a key was altered.
+func OpenKeystore(data []byte, operatorpass string) (*Keystore, error) {
+	var stored keystoreJSON
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Format != keystoreFormat {
+		return nil, ErrKeystoreFormat
+	}
+	params := ScryptParams{N: stored.N, R: stored.R, P: stored.P}
+	if err := validateMaster(params); err != nil {
+		return nil, err
+	}
+	kek, err := masterKey(context.Background(), operatorpass, stored.Salt, params)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = open(&kek, stored.Check); err != nil {
+		return nil, ErrKeystorePassphrase
+	}
+	ks := &Keystore{current: stored.Current, params: params, salt: stored.Salt, kek: kek, keys: stored.Keys}
+	if ks.keys == nil {
+		ks.keys = make(map[int][]byte)
+	}
+	for version := range ks.keys {
+		if _, err = ks.unwrap(version); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := ks.keys[ks.current]; !ok && len(ks.keys) > 0 {
+		return nil, ErrKeystoreFormat
+	}
+	return ks, nil
+}
+
+// LoadKeystore reads a Keystore from the file at path, which must not be accessible by group or others
+func LoadKeystore(path, operatorpass string) (*Keystore, error) {
+	data, err := readPrivateFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return OpenKeystore(data, operatorpass)
+}
+
+// Save writes the Keystore to the file at path with mode 0600, replacing it atomically
+func (ks *Keystore) Save(path string) error {
+	data, err := ks.Encode()
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err = f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Encode returns the JSON encoding of the Keystore
+func (ks *Keystore) Encode() ([]byte, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	check, err := seal(nil, &ks.kek, nil)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(keystoreJSON{
+		Format:  keystoreFormat,
+		Current: ks.current,
+		N:       ks.params.N,
+		R:       ks.params.R,
+		P:       ks.params.P,
+		Salt:    ks.salt,
+		Check:   check,
+		Keys:    ks.keys,
+	}, "", "  ")
+}
+
+// AddVersion takes version as int and master passphrase as string and seals it into the Keystore. The first version
+// added becomes the current version, later versions must be made current with SetCurrent.
+func (ks *Keystore) AddVersion(version int, masterpass string) error {
+	if len(masterpass) < MinLength {
+		return ErrPassphraseLength
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if _, ok := ks.keys[version]; ok {
+		return ErrDuplicateMasterVersion
+	}
+	sealed, err := ks.wrap(version, masterpass)
+	if err != nil {
+		return err
+	}
+	if len(ks.keys) == 0 {
+		ks.current = version
+	}
+	ks.keys[version] = sealed
+	return nil
+}
+
+// Retire takes version as int and removes its master passphrase from the Keystore, the current version can not be retired.
+// Hashes under a retired version can no longer be verified, rotate them first with RotateStream.
+func (ks *Keystore) Retire(version int) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if _, ok := ks.keys[version]; !ok || version == ks.current {
+		return ErrUnknownMasterVersion
+	}
+	delete(ks.keys, version)
+	return nil
+}
+
+// SetCurrent takes version as int and designates it as the version used for new hashes
+func (ks *Keystore) SetCurrent(version int) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if _, ok := ks.keys[version]; !ok {
+		return ErrUnknownMasterVersion
+	}
+	ks.current = version
+	return nil
+}
+
+// ChangePassphrase takes the new operator passphrase as string and params as ScryptParams and re-seals every master
+// passphrase under a key encryption key derived from it with a new salt
+func (ks *Keystore) ChangePassphrase(operatorpass string, params ScryptParams) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	masters := make(map[int]string, len(ks.keys))
+	for version := range ks.keys {
+		masterpass, err := ks.unwrap(version)
+		if err != nil {
+			return err
+		}
+		masters[version] = masterpass
+	}
+	next := &Keystore{current: ks.current}
+	if err := next.setPassphrase(operatorpass, params); err != nil {
+		return err
+	}
+	keys := make(map[int][]byte, len(masters))
+	for version, masterpass := range masters {
+		sealed, err := next.wrap(version, masterpass)
+		if err != nil {
+			return err
+		}
+		keys[version] = sealed
+	}
+	ks.params, ks.salt, ks.kek = next.params, next.salt, next.kek
+	ks.keys = keys
+	return nil
+}
+
+// Versions returns the master passphrase versions held by the Keystore in ascending order
+func (ks *Keystore) Versions() []int {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	versions := make([]int, 0, len(ks.keys))
+	for v := range ks.keys {
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// GetKey returns the master passphrase for version
+func (ks *Keystore) GetKey(version int) (string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.unwrap(version)
+}
+
+// CurrentVersion returns the master passphrase version used for new hashes, or ErrUnknownMasterVersion if the
+// Keystore is empty
+func (ks *Keystore) CurrentVersion() (int, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if _, ok := ks.keys[ks.current]; !ok {
+		return 0, ErrUnknownMasterVersion
+	}
+	return ks.current, nil
+}
+
+// setPassphrase derives a new key encryption key with a new salt, ks.mu must be held if ks is shared
+func (ks *Keystore) setPassphrase(operatorpass string, params ScryptParams) error {
+	if len(operatorpass) < MinLength {
+		return ErrPassphraseLength
+	}
+	if err := validateMaster(params); err != nil {
+		return err
+	}
+	kek, salt, err := newMasterKey(context.Background(), nil, operatorpass, params)
+	if err != nil {
+		return err
+	}
+	ks.params, ks.salt, ks.kek = params, salt, kek
+	return nil
+}
+
+// wrap seals masterpass prefixed by its version so that sealed keys can not be swapped between versions
+func (ks *Keystore) wrap(version int, masterpass string) ([]byte, error) {
+	plaintext := make([]byte, 8, 8+len(masterpass))
+	binary.BigEndian.PutUint64(plaintext, uint64(int64(version)))
+	return seal(nil, &ks.kek, append(plaintext, masterpass...))
+}
+
+// unwrap opens the sealed master passphrase of version, ks.mu must be held
+func (ks *Keystore) unwrap(version int) (string, error) {
+	sealed, ok := ks.keys[version]
+	if !ok {
+		return "", ErrUnknownMasterVersion
+	}
+	plaintext, err := open(&ks.kek, sealed)
+	if err != nil || len(plaintext) < 8 || int64(binary.BigEndian.Uint64(plaintext)) != int64(version) {
+		return "", ErrKeystorePassphrase
+	}
+	return string(plaintext[8:]), nil
+}
@@ -0,0 +1,152 @@
+package password
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestKeystore(t *testing.T) {
+	ks, err := NewKeystore("operatorpassphrase", DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if _, err = ks.CurrentVersion(); err != ErrUnknownMasterVersion {
+		t.Log("Expected empty keystore to have no current version")
+		t.FailNow()
+	}
+	if err = ks.AddVersion(0, "masterpassphrase"); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err = ks.AddVersion(1, "masterpassphrase1"); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err = ks.AddVersion(1, "masterpassphrase1"); err != ErrDuplicateMasterVersion {
+		t.Log("Expected ErrDuplicateMasterVersion")
+		t.FailNow()
+	}
+	if v, _ := ks.CurrentVersion(); v != 0 {
+		t.Log("Expected first version added to be current")
+		t.FailNow()
+	}
+	if err = ks.SetCurrent(1); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err = ks.Retire(1); err != ErrUnknownMasterVersion {
+		t.Log("Expected current version retire to fail")
+		t.FailNow()
+	}
+
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	if err = ks.Save(path); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Log("Expected keystore file mode 0600", err)
+		t.FailNow()
+	}
+	if _, err = LoadKeystore(path, "wrongoperatorpass"); err != ErrKeystorePassphrase {
+		t.Log("Expected ErrKeystorePassphrase, got", err)
+		t.FailNow()
+	}
+	loaded, err := LoadKeystore(path, "operatorpassphrase")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if masterpass, err := loaded.GetKey(0); err != nil || masterpass != "masterpassphrase" {
+		t.Log("Unexpected master passphrase", err)
+		t.FailNow()
+	}
+
+	// A Keystore is a KeyProvider
+	k, err := NewKeyringFromProvider(loaded, DefaultParams, 0)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	output, err := k.Hash("password1234", testArgon2Params)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err = Verify("password1234", "masterpassphrase1", output); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	if err = loaded.ChangePassphrase("newoperatorpassphrase", DefaultParams); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err = loaded.Retire(0); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	data, err := loaded.Encode()
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if _, err = OpenKeystore(data, "operatorpassphrase"); err != ErrKeystorePassphrase {
+		t.Log("Expected old operator passphrase to fail")
+		t.FailNow()
+	}
+	reopened, err := OpenKeystore(data, "newoperatorpassphrase")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if versions := reopened.Versions(); len(versions) != 1 || versions[0] != 1 {
+		t.Logf("Unexpected versions: %v", versions)
+		t.FailNow()
+	}
+	if _, err = reopened.GetKey(0); err != ErrUnknownMasterVersion {
+		t.Log("Expected retired version to be gone")
+		t.FailNow()
+	}
+
+	if _, err = OpenKeystore([]byte("{}"), "operatorpassphrase"); err != ErrKeystoreFormat {
+		t.Log("Expected ErrKeystoreFormat")
+		t.FailNow()
+	}
+	if runtime.GOOS == "windows" {
+		return
+	}
+	if err = os.Chmod(path, 0644); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if _, err = LoadKeystore(path, "operatorpassphrase"); !errors.Is(err, ErrKeyFilePermissions) {
+		t.Log("Expected ErrKeyFilePermissions")
+		t.FailNow()
+	}
+}
+
+func TestKeystoreSwappedKeys(t *testing.T) {
+	ks, err := NewKeystore("operatorpassphrase", DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	ks.AddVersion(0, "masterpassphrase")
+	ks.AddVersion(1, "masterpassphrase1")
+	// Sealed keys moved to another version are rejected
+	ks.keys[0], ks.keys[1] = ks.keys[1], ks.keys[0]
+	data, err := ks.Encode()
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if _, err = OpenKeystore(data, "operatorpassphrase"); err != ErrKeystorePassphrase {
+		t.Log("Expected swapped keys to be rejected")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,136 @@
+package password
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter bounds the memory used by concurrent Scrypt and Argon2 computations. Each computation is weighted by the
+// memory it allocates, Memory KiB for Argon2id and 128*N*R bytes plus the 128*R*P byte block buffer for Scrypt, and is
+// admitted once the sum of running weights stays within the memory ceiling. Scrypt is deliberately not weighted by
+// 128*N*R*P: golang.org/x/crypto/scrypt runs the P lanes one after another reusing one 128*N*R buffer, so multiplying
+// by P would overstate its memory P times and admit P times fewer computations than fit. Waiting computations are
+// admitted in arrival order. A Limiter is safe for concurrent use.
+type Limiter struct {
+	maxMemory uint64
+	maxQueue  int
+	timeout   time.Duration
+
+	mu      sync.Mutex
+	used    uint64
+	waiters list.List
+}
+
+// limiterWaiter is a computation queued for admission, ready is closed once admitted
+type limiterWaiter struct {
+	weight uint64
+	ready  chan struct{}
+}
+
+// NewLimiter takes maxMemory in bytes, maxQueue as the number of computations allowed to wait for admission and
+// timeout as the longest a computation waits, and returns a Limiter. A maxQueue of 0 rejects computations that cannot
+// be admitted immediately, and a timeout of 0 waits until admitted. Computations weighing more than maxMemory are
+// admitted alone.
+func NewLimiter(maxMemory uint64, maxQueue int, timeout time.Duration) *Limiter {
+	return &Limiter{maxMemory: maxMemory, maxQueue: maxQueue, timeout: timeout}
+}
+
+// limiter is the Limiter all package KDF computations are admitted through, nil for no limit
+var limiter struct {
+	sync.RWMutex
+	l *Limiter
+}
+
+// SetLimiter sets the Limiter every Scrypt and Argon2 computation of the package goes through, including those of
+// Hash, Verify, UpdateMaster, Hasher and Keyring. A nil Limiter, the default, removes the limit.
+func SetLimiter(l *Limiter) {
+	limiter.Lock()
+	limiter.l = l
+	limiter.Unlock()
+}
+
+// admit waits for admission of a computation of weight bytes through the package Limiter and returns the function
+// releasing it. It returns ErrOverloaded if the queue is full or the Limiter timeout passes, or ctx.Err().
+func admit(ctx context.Context, weight uint64) (release func(), err error) {
+	limiter.RLock()
+	l := limiter.l
+	limiter.RUnlock()
+	if l == nil {
+		return func() {}, nil
+	}
+	return l.acquire(ctx, weight)
+}
+
+func (l *Limiter) acquire(ctx context.Context, weight uint64) (release func(), err error) {
+	if weight > l.maxMemory {
+		weight = l.maxMemory
+	}
+	release = func() { l.release(weight) }
+	l.mu.Lock()
+	if l.waiters.Len() == 0 && l.used+weight <= l.maxMemory {
+		l.used += weight
+		l.mu.Unlock()
+		return release, nil
+	}
+	if l.waiters.Len() >= l.maxQueue {
+		l.mu.Unlock()
+		return nil, ErrOverloaded
+	}
+	w := &limiterWaiter{weight: weight, ready: make(chan struct{})}
+	elem := l.waiters.PushBack(w)
+	l.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if l.timeout > 0 {
+		timer := time.NewTimer(l.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-w.ready:
+		return release, nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout:
+		err = ErrOverloaded
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-w.ready:
+		// Admitted while giving up, hand the memory on
+		l.used -= weight
+		l.notify()
+	default:
+		l.waiters.Remove(elem)
+		// A large computation leaving the front of the queue may let smaller ones behind it in
+		l.notify()
+	}
+	return nil, err
+}
+
+func (l *Limiter) release(weight uint64) {
+	l.mu.Lock()
+	l.used -= weight
+	l.notify()
+	l.mu.Unlock()
+}
+
+// notify admits waiters from the front of the queue while they fit, l.mu must be held
+func (l *Limiter) notify() {
+	for {
+		front := l.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(*limiterWaiter)
+		if l.used+w.weight > l.maxMemory {
+			return
+		}
+		l.used += w.weight
+		l.waiters.Remove(front)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,99 @@
+package password
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(100, 1, 50*time.Millisecond)
+	release, err := l.acquire(context.Background(), 60)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	// Does not fit, queue is one deep
+	admitted := make(chan error, 1)
+	go func() {
+		r, err := l.acquire(context.Background(), 60)
+		if err == nil {
+			r()
+		}
+		admitted <- err
+	}()
+	for {
+		l.mu.Lock()
+		queued := l.waiters.Len()
+		l.mu.Unlock()
+		if queued == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err = l.acquire(context.Background(), 10); err != ErrOverloaded {
+		t.Log("Expected ErrOverloaded for full queue")
+		t.FailNow()
+	}
+	release()
+	if err = <-admitted; err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	// Times out waiting
+	release, _ = l.acquire(context.Background(), 100)
+	start := time.Now()
+	if _, err = l.acquire(context.Background(), 1); err != ErrOverloaded {
+		t.Log("Expected ErrOverloaded after timeout")
+		t.FailNow()
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Log("Expected wait for timeout")
+		t.FailNow()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = l.acquire(ctx, 1); err != context.Canceled {
+		t.Log("Expected context.Canceled")
+		t.FailNow()
+	}
+	release()
+	if l.used != 0 || l.waiters.Len() != 0 {
+		t.Logf("Expected empty limiter, used %v queued %v", l.used, l.waiters.Len())
+		t.FailNow()
+	}
+
+	// Computations larger than the ceiling are admitted alone
+	release, err = l.acquire(context.Background(), 1000)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	release()
+}
+
+func TestSetLimiter(t *testing.T) {
+	l := NewLimiter(scryptMemory(DefaultParams), 0, 0)
+	SetLimiter(l)
+	defer SetLimiter(nil)
+	output, err := HashV2("password1234", "masterpassphrase", 0, testArgon2Params, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	release, err := l.acquire(context.Background(), 1)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err = Verify("password1234", "masterpassphrase", output); err != ErrOverloaded {
+		t.Log("Expected ErrOverloaded")
+		t.FailNow()
+	}
+	release()
+	if err = Verify("password1234", "masterpassphrase", output); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,89 @@
+package password
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/text/secure/precis"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// NormNFC normalizes passphrases to Unicode Normalization Form C, so precomposed and decomposed accented
+	// characters are equal
+	NormNFC = "nfc"
+	// NormNFKC normalizes passphrases to Unicode Normalization Form KC, additionally folding compatibility characters
+	// such as full width letters and ligatures
+	NormNFKC = "nfkc"
+	// NormOpaqueString prepares passphrases with the PRECIS OpaqueString profile of RFC 8265, mapping non-ASCII spaces
+	// to ASCII space, applying NFC and rejecting control and other disallowed characters
+	NormOpaqueString = "opaquestring"
+)
+
+// NormalizedParams wraps the UserParams of any KDF so that the user passphrase is normalized by Form before it is
+// hashed. The form is recorded in secBoxv2 and secBoxv3 hashes as a norm parameter following the KDF parameters,
+// ex. m=65536,t=3,p=4,l=32,norm=nfc, so Verify applies the same normalization used when the hash was created.
+type NormalizedParams struct {
+	Params UserParams
+	Form   string
+}
+
+// KDF returns the KDF identifier of the wrapped UserParams
+func (p NormalizedParams) KDF() string { return p.Params.KDF() }
+
+func (p NormalizedParams) encode() string { return p.Params.encode() + ",norm=" + p.Form }
+
+func (p NormalizedParams) size() int { return p.Params.size() }
+
+func (p NormalizedParams) validate() error {
+	switch p.Form {
+	case NormNFC, NormNFKC, NormOpaqueString:
+	default:
+		return ErrUnsupportedNormalization
+	}
+	if _, ok := p.Params.(NormalizedParams); ok || p.Params == nil {
+		return ErrUnsupportedKDF
+	}
+	return p.Params.validate()
+}
+
+func (p NormalizedParams) hash(ctx context.Context, random io.Reader, userpass string) ([]byte, error) {
+	normalized, err := normalize(p.Form, userpass)
+	if err != nil {
+		return nil, err
+	}
+	return p.Params.hash(ctx, random, normalized)
+}
+
+func (p NormalizedParams) verify(ctx context.Context, userpass string, decrypted []byte) error {
+	normalized, err := normalize(p.Form, userpass)
+	if err != nil {
+		// The hash was created from a passphrase accepted by the profile, so this one can not match
+		return ErrPassphraseHashMismatch
+	}
+	return p.Params.verify(ctx, normalized, decrypted)
+}
+
+func (p NormalizedParams) synthetic(random io.Reader) ([]byte, error) {
+	if s, ok := p.Params.(syntheticOutput); ok {
+		return s.synthetic(random)
+	}
+	return randBytes(random, p.size())
+}
+
+// normalize returns userpass normalized by form
+func normalize(form, userpass string) (string, error) {
+	switch form {
+	case NormNFC:
+		return norm.NFC.String(userpass), nil
+	case NormNFKC:
+		return norm.NFKC.String(userpass), nil
+	case NormOpaqueString:
+		normalized, err := precis.OpaqueString.String(userpass)
+		if err != nil {
+			return "", ErrPassphraseNormalization
+		}
+		return normalized, nil
+	}
+	return "", ErrUnsupportedNormalization
+}
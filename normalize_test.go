@@ -0,0 +1,83 @@
+package password
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizedParams(t *testing.T) {
+	// "é" precomposed as U+00E9 and decomposed as e followed by U+0301
+	precomposed := "caf\u00e9password"
+	decomposed := "cafe\u0301password"
+	for _, form := range []string{NormNFC, NormNFKC, NormOpaqueString} {
+		params := NormalizedParams{Params: testArgon2Params, Form: form}
+		output, err := HashV2(precomposed, "masterpassphrase", 0, params, DefaultParams)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if !strings.Contains(output, "$argon2id$m=8192,t=1,p=2,l=32,norm="+form+"$") {
+			t.Logf("Unexpected output: %s", output)
+			t.FailNow()
+		}
+		if err = Verify(decomposed, "masterpassphrase", output); err != nil {
+			t.Log(form, err)
+			t.FailNow()
+		}
+		if err = Verify("cafepassword", "masterpassphrase", output); err != ErrPassphraseHashMismatch {
+			t.Log("Expected passphrase mismatch")
+			t.FailNow()
+		}
+		parsed, err := ParseHash(output)
+		if err != nil || parsed.UserParams != params {
+			t.Log("Expected parsed normalization form", err)
+			t.FailNow()
+		}
+		phc, err := EncodePHC(output)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if decoded, err := DecodePHC(phc); err != nil || decoded != output {
+			t.Log("Expected PHC round trip", err)
+			t.FailNow()
+		}
+	}
+
+	// Without normalization the forms differ
+	output, err := HashV2(precomposed, "masterpassphrase", 0, testArgon2Params, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err = Verify(decomposed, "masterpassphrase", output); err != ErrPassphraseHashMismatch {
+		t.Log("Expected passphrase mismatch without normalization")
+		t.FailNow()
+	}
+
+	// NFKC folds full width characters, NFC does not
+	fullwidth := "\uff50\uff41\uff53\uff53\uff57\uff4f\uff52\uff44"
+	output, err = HashV2("password", "masterpassphrase", 0, NormalizedParams{Params: testArgon2Params, Form: NormNFKC}, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err = Verify(fullwidth, "masterpassphrase", output); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	opaque := NormalizedParams{Params: testArgon2Params, Form: NormOpaqueString}
+	if _, err = HashV2("pass\x07word1234", "masterpassphrase", 0, opaque, DefaultParams); err != ErrPassphraseNormalization {
+		t.Log("Expected ErrPassphraseNormalization, got", err)
+		t.FailNow()
+	}
+	if _, err = HashV2("password1234", "masterpassphrase", 0, NormalizedParams{Params: testArgon2Params, Form: "nfd"}, DefaultParams); err != ErrUnsupportedNormalization {
+		t.Log("Expected ErrUnsupportedNormalization")
+		t.FailNow()
+	}
+	if _, err = ParseUserParams(KDFArgon2id, "m=8192,t=1,p=1,l=32,norm=nfx"); err != ErrUnsupportedNormalization {
+		t.Log("Expected ErrUnsupportedNormalization")
+		t.FailNow()
+	}
+}
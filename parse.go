@@ -0,0 +1,182 @@
+package password
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// ParsedHash holds the decoded fields of a secBoxv1, secBoxv2 or secBoxv3 hash
+type ParsedHash struct {
+	// Version is the hash format version, ex. 2 for secBoxv2
+	Version int
+	// MasterVersion is the master passphrase version
+	MasterVersion int
+	// Cipher is the master layer cipher, always CipherSecretbox for Version 1 and 2
+	Cipher string
+	// Nonce is the cipher nonce, 24 bytes for secretbox and XChaCha20-Poly1305 or 12 bytes for AES-256-GCM
+	Nonce []byte
+	// Box is the sealed user passphrase KDF output including the authentication tag
+	Box []byte
+	// MasterSalt is the master passphrase Scrypt salt
+	MasterSalt []byte
+	// UserParams are the user passphrase KDF parameters, always ScryptParams for Version 1
+	UserParams UserParams
+	// MasterParams are the master passphrase Scrypt parameters
+	MasterParams ScryptParams
+}
+
+// ParseHash takes ciphertext string in secBoxv1, secBoxv2, secBoxv3 or PHC string format and returns its decoded fields and error.
+// Errors are *FormatError identifying the malformed field, which match ErrCiphertextFormat with errors.Is.
+func ParseHash(ciphertext string) (*ParsedHash, error) {
+	if strings.HasPrefix(ciphertext, "$") {
+		return parsePHC(ciphertext)
+	}
+	parts := strings.Split(ciphertext, "$")
+	h := &ParsedHash{Cipher: CipherSecretbox}
+	// Indexes of the fields common to both versions
+	var ctIdx, saltIdx, masterIdx int
+	switch parts[0] {
+	case "secBoxv1":
+		if len(parts) != 10 {
+			return nil, fieldCountError(10, len(parts))
+		}
+		h.Version = 1
+		ctIdx, saltIdx, masterIdx = 2, 3, 7
+		p, err := parseScryptFields(parts[4:7], 4, LayerUser)
+		if err != nil {
+			return nil, err
+		}
+		h.UserParams = p
+	case "secBoxv2":
+		if len(parts) != 9 {
+			return nil, fieldCountError(9, len(parts))
+		}
+		h.Version = 2
+		ctIdx, saltIdx, masterIdx = 4, 5, 6
+		p, err := parseUserFields(parts[2:4], 2)
+		if err != nil {
+			return nil, err
+		}
+		h.UserParams = p
+	case "secBoxv3":
+		if len(parts) != 10 {
+			return nil, fieldCountError(10, len(parts))
+		}
+		h.Version = 3
+		ctIdx, saltIdx, masterIdx = 5, 6, 7
+		if err := validateCipher(parts[2]); err != nil {
+			return nil, &FormatError{Field: "cipher", Index: 2, Cause: err}
+		}
+		h.Cipher = parts[2]
+		p, err := parseUserFields(parts[3:5], 3)
+		if err != nil {
+			return nil, err
+		}
+		h.UserParams = p
+	default:
+		return nil, &FormatError{Field: "identifier", Index: 0, Cause: ErrCiphertextVer}
+	}
+	var err error
+	if h.MasterVersion, err = strconv.Atoi(parts[1]); err != nil {
+		return nil, &FormatError{Field: "master version", Index: 1, Cause: err}
+	}
+	if h.MasterParams, err = parseScryptFields(parts[masterIdx:masterIdx+3], masterIdx, LayerMaster); err != nil {
+		return nil, err
+	}
+	encrypted, err := base64.StdEncoding.DecodeString(parts[ctIdx])
+	if err != nil {
+		return nil, &FormatError{Field: "ciphertext", Index: ctIdx, Cause: err}
+	}
+	if h.Nonce, h.Box, err = splitEncrypted(h.Cipher, encrypted); err != nil {
+		return nil, &FormatError{Field: "ciphertext", Index: ctIdx, Cause: err}
+	}
+	if h.MasterSalt, err = base64.StdEncoding.DecodeString(parts[saltIdx]); err != nil {
+		return nil, &FormatError{Field: "master salt", Index: saltIdx, Cause: err}
+	}
+	return h, nil
+}
+
+// String formats the hash fields as ciphertext string in secBoxv1, secBoxv2 or secBoxv3 format per Version
+func (h *ParsedHash) String() string {
+	ciphertext := base64.StdEncoding.EncodeToString(h.encrypted())
+	salt := base64.StdEncoding.EncodeToString(h.MasterSalt)
+	m := h.MasterParams
+	if u, ok := h.UserParams.(ScryptParams); ok && h.Version == 1 {
+		return fmt.Sprintf("secBoxv1$%v$%s$%s$%v$%v$%v$%v$%v$%v", h.MasterVersion, ciphertext, salt, u.N, u.R, u.P, m.N, m.R, m.P)
+	}
+	if h.Version == 3 {
+		return fmt.Sprintf("secBoxv3$%v$%s$%s$%s$%s$%s$%v$%v$%v", h.MasterVersion, h.Cipher, h.UserParams.KDF(), h.UserParams.encode(), ciphertext, salt, m.N, m.R, m.P)
+	}
+	return fmt.Sprintf("secBoxv2$%v$%s$%s$%s$%s$%v$%v$%v", h.MasterVersion, h.UserParams.KDF(), h.UserParams.encode(), ciphertext, salt, m.N, m.R, m.P)
+}
+
+// encrypted returns the nonce followed by the sealed box as produced by sealWith
+func (h *ParsedHash) encrypted() []byte {
+	out := make([]byte, 0, len(h.Nonce)+len(h.Box))
+	return append(append(out, h.Nonce...), h.Box...)
+}
+
+// open decrypts the box using key derived from the master passphrase
+func (h *ParsedHash) open(key *[32]byte) ([]byte, error) {
+	return openWith(h.Cipher, key, h.encrypted())
+}
+
+// seal replaces the nonce and box with plaintext encrypted under key using the hash cipher and a nonce read from random
+func (h *ParsedHash) seal(random io.Reader, key *[32]byte, plaintext []byte) error {
+	sealed, err := sealWith(random, h.Cipher, key, plaintext)
+	if err != nil {
+		return err
+	}
+	h.Nonce, h.Box, err = splitEncrypted(h.Cipher, sealed)
+	return err
+}
+
+// splitEncrypted splits cipher output prefixed by its nonce
+func splitEncrypted(cipher string, encrypted []byte) (nonce, box []byte, err error) {
+	n, err := cipherNonceSize(cipher)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(encrypted) < n+secretbox.Overhead {
+		return nil, nil, fmt.Errorf("decoded length %v shorter than nonce and authenticator", len(encrypted))
+	}
+	return encrypted[:n], encrypted[n:], nil
+}
+
+// parseUserFields parses the user KDF and its parameters from consecutive fields starting at index
+func parseUserFields(fields []string, index int) (UserParams, error) {
+	p, err := ParseUserParams(fields[0], fields[1])
+	if err == ErrUnsupportedKDF {
+		return nil, &FormatError{Field: "user kdf", Index: index, Cause: err}
+	}
+	if err != nil {
+		return nil, &FormatError{Field: "user params", Index: index + 1, Cause: withLayer(err, LayerUser)}
+	}
+	return p, nil
+}
+
+// parseScryptFields parses N, R and P from consecutive fields starting at index
+func parseScryptFields(fields []string, index int, layer string) (p ScryptParams, err error) {
+	for i, v := range []*int{&p.N, &p.R, &p.P} {
+		if *v, err = strconv.Atoi(fields[i]); err != nil {
+			return p, &FormatError{Field: layer + " " + []string{"N", "R", "P"}[i], Index: index + i, Cause: err}
+		}
+	}
+	if err = validateParams(p); err != nil {
+		return p, &FormatError{Field: layer + " params", Index: index, Cause: withLayer(err, layer)}
+	}
+	return p, nil
+}
+
+func fieldCountError(want, got int) error {
+	return &FormatError{Field: "field count", Index: -1, Cause: fmt.Errorf("expected %v fields, got %v", want, got)}
+}
+
+// errNotCanonical indicates a hash that decodes but is not in the single valid encoding of its fields
+var errNotCanonical = errors.New("not canonically encoded")
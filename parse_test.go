@@ -0,0 +1,101 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseHash(t *testing.T) {
+	parsed, err := ParseHash(testV1Hash)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if parsed.Version != 1 || parsed.MasterVersion != 0 || len(parsed.Nonce) != 24 || len(parsed.MasterSalt) != 8 {
+		t.Logf("Unexpected fields %+v", parsed)
+		t.FailNow()
+	}
+	if parsed.UserParams != (ScryptParams{N: 32768, R: 16, P: 1}) || parsed.MasterParams != DefaultParams {
+		t.Log("Unexpected parameters")
+		t.FailNow()
+	}
+	if parsed.String() != testV1Hash {
+		t.Log("Expected exact round trip")
+		t.FailNow()
+	}
+
+	v2, err := HashV2("password1234", "masterpassphrase", 3, testArgon2Params, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	parsed, err = ParseHash(v2)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if parsed.Version != 2 || parsed.MasterVersion != 3 || parsed.UserParams != testArgon2Params || parsed.String() != v2 {
+		t.Logf("Unexpected fields %+v", parsed)
+		t.FailNow()
+	}
+	phc, err := EncodePHC(v2)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if parsed.PHC() != phc {
+		t.Log("Expected PHC to match EncodePHC")
+		t.FailNow()
+	}
+	fromPHC, err := ParseHash(phc)
+	if err != nil || fromPHC.String() != v2 {
+		t.Log("Expected PHC input to parse to the same hash")
+		t.FailNow()
+	}
+}
+
+func TestParseHashErrors(t *testing.T) {
+	parts := strings.Split(testV1Hash, "$")
+	tests := []struct {
+		index int
+		value string
+		field string
+	}{
+		{0, "secBoxv9", "identifier"},
+		{1, "x", "master version"},
+		{2, "!!!", "ciphertext"},
+		{2, "AAAA", "ciphertext"},
+		{3, "!!!", "master salt"},
+		{5, "x", "user R"},
+		{8, "0", "master params"},
+	}
+	for _, test := range tests {
+		bad := append([]string(nil), parts...)
+		bad[test.index] = test.value
+		_, err := ParseHash(strings.Join(bad, "$"))
+		var ferr *FormatError
+		if !errors.As(err, &ferr) || ferr.Field != test.field || !errors.Is(err, ErrCiphertextFormat) {
+			t.Logf("Expected %s format error for field %v, got %v", test.field, test.index, err)
+			t.FailNow()
+		}
+		if ferr.Index != test.index && test.field != "master params" {
+			t.Logf("Expected index %v, got %v", test.index, ferr.Index)
+			t.FailNow()
+		}
+	}
+	if _, err := ParseHash("secBoxv9$0"); !errors.Is(err, ErrCiphertextVer) {
+		t.Log("Expected unknown version error")
+		t.FailNow()
+	}
+	for _, s := range []string{"", "$", "secBoxv1", "secBoxv2$0$argon2id"} {
+		if _, err := ParseHash(s); !errors.Is(err, ErrCiphertextFormat) {
+			t.Logf("Expected format error for %q", s)
+			t.FailNow()
+		}
+		if _, err := GetMasterVersion(s); err == nil {
+			t.Logf("Expected GetMasterVersion error for %q", s)
+			t.FailNow()
+		}
+	}
+}
@@ -1,13 +1,17 @@
 // Package password is a probably paranoid utility library for securly hashing and
 // encrypting passwords based on the Dropbox method. This implementation uses Blake2b,
 // Scrypt and XSalsa20-Poly1305 (via NaCl SecretBox) to create secure password hashes
-// that are also encrypted using a master passphrase. If the master passphrase is lost
+// that are also encrypted using a master passphrase. The secBoxv2 format produced by
+// HashV2 additionally allows Argon2id to be used for the user passphrase, and the secBoxv3 format produced by
+// HashV3 allows XChaCha20-Poly1305 or AES-256-GCM to be used in place of secretbox. If the master passphrase is lost
 // you will lose access to all passwords encrypted with it so store is securely, my
 // recommendation is that you store it as an environmental variable or in a config file
-// to avoid storing it in source code.
+// to avoid storing it in source code, or supply it through a KeyProvider such as EnvelopeProvider
+// so that only a wrapped master passphrase is held in configuration.
 package password
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/subtle"
 	"encoding/base64"
@@ -24,7 +28,8 @@ import (
 )
 
 var (
-	// MinLength changes the minimum passphrase and master passphrase length accepted
+	// MinLength changes the minimum passphrase and master passphrase length accepted, a Hasher or Keyring with a
+	// Policy set checks user passphrases against the Policy instead
 	MinLength = 8
 	// DefaultParams defines Scrypt Parameters
 	DefaultParams = ScryptParams{N: 16384, R: 8, P: 1}
@@ -38,6 +43,7 @@ type ScryptParams struct {
 }
 
 // Hash takes passphrase ,masterpassphrase as strings, version indicator as int, and userparams and masterparams as ScryptParams and returns up to 225 char ciphertext string and error - ex. password.Hash("password1234", "masterpassphrase", 0, ScryptParams{N: 32768, R: 16, P: 1}, DefaultParams)
+// The user passphrase is only checked against MinLength, to apply a Policy call Policy.Check first or use a Hasher.
 func Hash(userpass, masterpass string, version int, userparams, masterparams ScryptParams) (pwHashOut string, err error) {
 	sbpVersion := "v1"
 	// Check for non-nil and at least min length password and masterKey
@@ -48,11 +54,11 @@ func Hash(userpass, masterpass string, version int, userparams, masterparams Scr
 		return "", ErrPassphraseLength
 	}
 	// Validate Scrypt Parameters
-	err = validateParams(userparams)
+	err = validateUser(userparams)
 	if err != nil {
 		return
 	}
-	err = validateParams(masterparams)
+	err = validateMaster(masterparams)
 	if err != nil {
 		return
 	}
@@ -60,10 +66,20 @@ func Hash(userpass, masterpass string, version int, userparams, masterparams Scr
 	// 1) The plaintext password is transformed into a hash value using Blake2b-512
 	userPwBlake := blake2b.Sum512([]byte(userpass))
 	// 2) Blake2b hash is hashed again using Scrypt with supplied params plus random 8 byte salt, generating 56 byte output with salt appended for 64 byte total output
-	userpassScrypt, err := scryptHash(hex.EncodeToString(userPwBlake[:]), nil, userparams)
+	userSalt, err := randBytes(nil, 8)
+	if err != nil {
+		return
+	}
+	userpassScrypt, err := scryptHash(context.Background(), hex.EncodeToString(userPwBlake[:]), userSalt, userparams)
+	if err != nil {
+		return
+	}
 
 	// 3) Encrypt userpass Scrypt output with secretbox XSalsa20-Poly1305 encryption-authentication method using random 24 byte nonce and masterpass Scrypt hash
 	encrypted, salt, err := encrypt(masterpass, userpassScrypt, masterparams)
+	if err != nil {
+		return
+	}
 	// 4) Generate base64 of Secretbox output and salt then format output string and return
 	ciphertext := base64.StdEncoding.EncodeToString(encrypted)
 	saltHex := base64.StdEncoding.EncodeToString(salt)
@@ -71,51 +87,176 @@ func Hash(userpass, masterpass string, version int, userparams, masterparams Scr
 	return pwHashOut, err
 }
 
-// Verify takes passphrase, masterpassphrase and ciphertext as strings and returns error if verification fails, else returns nil upon success
+// HashV2 takes passphrase ,masterpassphrase as strings, version indicator as int, userparams as ScryptParams or Argon2Params and masterparams as ScryptParams and returns secBoxv2 ciphertext string and error - ex. password.HashV2("password1234", "masterpassphrase", 0, DefaultArgon2Params, DefaultParams)
+func HashV2(userpass, masterpass string, version int, userparams UserParams, masterparams ScryptParams) (pwHashOut string, err error) {
+	sbpVersion := "v2"
+	// Check for non-nil and at least min length password and masterKey
+	if len(userpass) < MinLength {
+		return "", ErrPassphraseLength
+	}
+	if len(masterpass) < MinLength {
+		return "", ErrPassphraseLength
+	}
+	// Validate KDF Parameters
+	err = validateUser(userparams)
+	if err != nil {
+		return
+	}
+	err = validateMaster(masterparams)
+	if err != nil {
+		return
+	}
+
+	// 1) The plaintext password is hashed using Blake2b-512 and then the selected KDF, with the KDF salt appended
+	userpassHash, err := userparams.hash(context.Background(), nil, userpass)
+	if err != nil {
+		return
+	}
+	// 2) Encrypt userpass KDF output with secretbox XSalsa20-Poly1305 encryption-authentication method using random 24 byte nonce and masterpass Scrypt hash
+	encrypted, salt, err := encrypt(masterpass, userpassHash, masterparams)
+	if err != nil {
+		return
+	}
+	// 3) Generate base64 of Secretbox output and salt then format output string and return
+	ciphertext := base64.StdEncoding.EncodeToString(encrypted)
+	saltHex := base64.StdEncoding.EncodeToString(salt)
+	pwHashOut = fmt.Sprintf("secBox%s$%v$%s$%s$%s$%s$%v$%v$%v", sbpVersion, version, userparams.KDF(), userparams.encode(), ciphertext, saltHex, masterparams.N, masterparams.R, masterparams.P)
+	return pwHashOut, err
+}
+
+// HashV3 takes passphrase, masterpassphrase as strings, version indicator as int, cipher as string, userparams as ScryptParams
+// or Argon2Params and masterparams as ScryptParams and returns secBoxv3 ciphertext string and error. The cipher is one of
+// CipherXChaCha20Poly1305 or CipherAES256GCM and is stored in the hash, for CipherSecretbox the secBoxv2 string HashV2
+// would return is given instead so that each hash has a single encoding
+// - ex. password.HashV3("password1234", "masterpassphrase", 0, CipherAES256GCM, DefaultArgon2Params, DefaultParams)
+func HashV3(userpass, masterpass string, version int, cipher string, userparams UserParams, masterparams ScryptParams) (pwHashOut string, err error) {
+	if len(userpass) < MinLength {
+		return "", ErrPassphraseLength
+	}
+	if len(masterpass) < MinLength {
+		return "", ErrPassphraseLength
+	}
+	if err = validateCipher(cipher); err != nil {
+		return
+	}
+	err = validateUser(userparams)
+	if err != nil {
+		return
+	}
+	err = validateMaster(masterparams)
+	if err != nil {
+		return
+	}
+	userpassHash, err := userparams.hash(context.Background(), nil, userpass)
+	if err != nil {
+		return
+	}
+	key, salt, err := newMasterKey(context.Background(), nil, masterpass, masterparams)
+	if err != nil {
+		return
+	}
+	out := &ParsedHash{
+		Version:       formatVersion(cipher),
+		MasterVersion: version,
+		Cipher:        cipher,
+		MasterSalt:    salt,
+		UserParams:    userparams,
+		MasterParams:  masterparams,
+	}
+	if err = out.seal(nil, &key, userpassHash); err != nil {
+		return
+	}
+	return out.String(), nil
+}
+
+// Verify takes passphrase, masterpassphrase and ciphertext as strings and returns error if verification fails, else returns nil upon success.
+// Ciphertext may also be given in PHC string format as produced by EncodePHC.
 func Verify(userpass, masterpass, ciphertext string) error {
+	ciphertext, err := secBoxLayout(ciphertext)
+	if err != nil {
+		return err
+	}
 	parts := strings.Split(ciphertext, "$")
 	if len(parts) == 10 && parts[0] == "secBoxv1" {
 		return verifyV1(userpass, masterpass, parts)
 	}
+	if len(parts) == 9 && parts[0] == "secBoxv2" {
+		return verifyV2(userpass, masterpass, parts)
+	}
+	if parts[0] == "secBoxv3" {
+		return verifyV3(userpass, masterpass, ciphertext)
+	}
 	return ErrCiphertextVer
 }
 
-// GetHashVersion takes ciphertext string and returns goSecretBoxPassword version as int and error.
+// secBoxLayout returns ciphertext converted from the PHC string format with DecodePHC, or unchanged if it is not a
+// PHC string
+func secBoxLayout(ciphertext string) (string, error) {
+	if strings.HasPrefix(ciphertext, "$"+phcID+"$") {
+		return DecodePHC(ciphertext)
+	}
+	return ciphertext, nil
+}
+
+// GetHashVersion takes ciphertext string in the secBox layout or PHC string format and returns goSecretBoxPassword
+// version as int and error.
 func GetHashVersion(ciphertext string) (version int, err error) {
-	parts := strings.Split(ciphertext, "$")
-	s := strings.Trim(parts[0], "secBoxv")
-	version, err = strconv.Atoi(s)
+	parsed, err := ParseHash(ciphertext)
 	if err != nil {
-		return
+		return 0, err
 	}
-	return
+	return parsed.Version, nil
 }
 
-// GetParams takes ciphertext string, returns user and master parameters and error. This may be useful for upgrading.
+// GetParams takes ciphertext string in the secBox layout or PHC string format, returns user and master parameters and
+// error. This may be useful for upgrading. For hashes using a user passphrase KDF other than Scrypt ErrUnsupportedKDF
+// is returned, use GetKDFParams instead.
 func GetParams(ciphertext string) (userParams, masterParams ScryptParams, err error) {
-	parts := strings.Split(ciphertext, "$")
-	if len(parts) == 10 && parts[0] == "secBoxv1" {
-		return getParams(parts)
+	parsed, err := ParseHash(ciphertext)
+	if err != nil {
+		return userParams, masterParams, err
+	}
+	userParams, ok := parsed.UserParams.(ScryptParams)
+	if !ok {
+		return userParams, parsed.MasterParams, ErrUnsupportedKDF
+	}
+	return userParams, parsed.MasterParams, nil
+}
+
+// GetKDFParams takes ciphertext string, returns user KDF parameters as ScryptParams or Argon2Params, master parameters and error.
+func GetKDFParams(ciphertext string) (userParams UserParams, masterParams ScryptParams, err error) {
+	parsed, err := ParseHash(ciphertext)
+	if err != nil {
+		return nil, masterParams, err
 	}
-	return userParams, masterParams, ErrCiphertextFormat
+	return parsed.UserParams, parsed.MasterParams, nil
 }
 
 // GetMasterVersion takes ciphertext string and returns master passphrase version as int and error.
 func GetMasterVersion(ciphertext string) (version int, err error) {
-	parts := strings.Split(ciphertext, "$")
-	version, err = strconv.Atoi(parts[1])
+	parsed, err := ParseHash(ciphertext)
 	if err != nil {
-		return
+		return 0, err
 	}
-	return
+	return parsed.MasterVersion, nil
 }
 
 // UpdateMaster takes new master passphrase, old master passphrase as string, new version as int, cipertext as string, and new ScryptParams. It returns and updated hash output string and error.
+// A PHC string is accepted and, as with Keyring.Rotate, the updated hash is returned in the secBox layout.
 func UpdateMaster(newMaster, oldMaster string, newVersion int, ciphertext string, masterparams ScryptParams) (pwHashOut string, err error) {
+	if ciphertext, err = secBoxLayout(ciphertext); err != nil {
+		return "", err
+	}
 	parts := strings.Split(ciphertext, "$")
 	if len(parts) == 10 && parts[0] == "secBoxv1" {
 		return updateMasterV1(newMaster, oldMaster, newVersion, parts, masterparams)
 	}
+	if len(parts) == 9 && parts[0] == "secBoxv2" {
+		return updateMasterV2(newMaster, oldMaster, newVersion, parts, masterparams)
+	}
+	if parts[0] == "secBoxv3" {
+		return updateMasterV3(newMaster, oldMaster, newVersion, ciphertext, masterparams)
+	}
 	return "", ErrCiphertextFormat
 }
 func updateMasterV1(newMaster, oldMaster string, newVersion int, parts []string, masterparams ScryptParams) (newHash string, err error) {
@@ -123,7 +264,7 @@ func updateMasterV1(newMaster, oldMaster string, newVersion int, parts []string,
 	// Update Secretbox Masterpass version
 	cVer, err := strconv.Atoi(parts[1])
 	if err != nil {
-		return
+		return "", &FormatError{Field: "master version", Index: 1, Cause: err}
 	}
 	if newVersion <= cVer {
 		return "", ErrInvalidVersionUpdate
@@ -133,10 +274,24 @@ func updateMasterV1(newMaster, oldMaster string, newVersion int, parts []string,
 	if err != nil {
 		return "", err
 	}
+	err = validateMaster(masterparams)
+	if err != nil {
+		return "", err
+	}
 	// Regenerate Blake2b-256 hash (32 bytes) using masterpass for secretbox
 	//masterpassHash := blake2b.Sum256([]byte(masterpass))
 	salt, err := base64.StdEncoding.DecodeString(parts[3])
-	masterpassScrypt, err := scryptHash(oldMaster, salt, oldMasterparams)
+	if err != nil {
+		return "", &FormatError{Field: "master salt", Index: 3, Cause: err}
+	}
+	encrypted, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", &FormatError{Field: "ciphertext", Index: 2, Cause: err}
+	}
+	if _, _, err = splitEncrypted(CipherSecretbox, encrypted); err != nil {
+		return "", &FormatError{Field: "ciphertext", Index: 2, Cause: err}
+	}
+	masterpassScrypt, err := scryptHash(context.Background(), oldMaster, salt, oldMasterparams)
 	if err != nil {
 		return "", err
 	}
@@ -146,10 +301,6 @@ func updateMasterV1(newMaster, oldMaster string, newVersion int, parts []string,
 	// encrypt the message. One way to achieve this is to store the nonce
 	// alongside the encrypted message. Above, we stored the nonce in the first
 	// 24 bytes of the encrypted text.
-	encrypted, err := base64.StdEncoding.DecodeString(parts[2])
-	if err != nil {
-		return "", err
-	}
 	var decryptNonce [24]byte
 	copy(decryptNonce[:], encrypted[:24])
 	decrypted, ok := secretbox.Open(nil, encrypted[24:], &decryptNonce, &mpScryptB2)
@@ -166,28 +317,114 @@ func updateMasterV1(newMaster, oldMaster string, newVersion int, parts []string,
 	newHash = fmt.Sprintf("secBox%s$%v$%s$%s$%v$%v$%v$%v$%v$%v", sbpVersion, newVersion, ciphertext, saltHex, userparams.N, userparams.R, userparams.P, masterparams.N, masterparams.R, masterparams.P)
 	return
 }
-func encrypt(masterpass string, userpassScrypt []byte, masterparams ScryptParams) (secretboxOut, salt []byte, err error) {
-	// Generate random salt for master passphrase Scrypt hash
-	salt = make([]byte, 8)
-	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
-		panic("rand salt failure")
+func updateMasterV2(newMaster, oldMaster string, newVersion int, parts []string, masterparams ScryptParams) (newHash string, err error) {
+	sbpVersion := "v2"
+	// Update Secretbox Masterpass version
+	cVer, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", &FormatError{Field: "master version", Index: 1, Cause: err}
+	}
+	if newVersion <= cVer {
+		return "", ErrInvalidVersionUpdate
+	}
+	// Extract KDF parameters from string, user parameters are carried over unchanged
+	_, oldMasterparams, err := getParamsV2(parts)
+	if err != nil {
+		return "", err
+	}
+	err = validateMaster(masterparams)
+	if err != nil {
+		return "", err
+	}
+	salt, err := base64.StdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return "", &FormatError{Field: "master salt", Index: 5, Cause: err}
+	}
+	encrypted, err := base64.StdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return "", &FormatError{Field: "ciphertext", Index: 4, Cause: err}
 	}
-	// Generate Scrypt hash of masterpassphrase
-	masterpassScrypt, err := scryptHash(masterpass, salt, masterparams)
+	decrypted, err := decrypt(oldMaster, salt, encrypted, oldMasterparams)
+	if err != nil {
+		return "", err
+	}
+	newEncrypted, newSalt, err := encrypt(newMaster, decrypted, masterparams)
 	if err != nil {
 		return
 	}
-	// Generate random nonce for secretbox
-	var nonce [24]byte
-	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
-		panic("rand nonce failure")
+	ciphertext := base64.StdEncoding.EncodeToString(newEncrypted)
+	saltHex := base64.StdEncoding.EncodeToString(newSalt)
+	newHash = fmt.Sprintf("secBox%s$%v$%s$%s$%s$%s$%v$%v$%v", sbpVersion, newVersion, parts[2], parts[3], ciphertext, saltHex, masterparams.N, masterparams.R, masterparams.P)
+	return
+}
+func updateMasterV3(newMaster, oldMaster string, newVersion int, ciphertext string, masterparams ScryptParams) (newHash string, err error) {
+	return UpdateMasterContext(context.Background(), newMaster, oldMaster, newVersion, ciphertext, masterparams)
+}
+func encrypt(masterpass string, userpassScrypt []byte, masterparams ScryptParams) (secretboxOut, salt []byte, err error) {
+	// Generate random salt and secretbox key from Scrypt hash of masterpassphrase
+	key, salt, err := newMasterKey(context.Background(), nil, masterpass, masterparams)
+	if err != nil {
+		return
 	}
-	// Create 32 byte hash of masterpass Scrypt output for Secretbox
-	mpScryptB2 := blake2b.Sum256(masterpassScrypt)
 	// Encrypt userpass output and salt using masterpass Scrypt hash as key with the result appended to the nonce.
-	secretboxOut = secretbox.Seal(nonce[:], userpassScrypt, &nonce, &mpScryptB2)
+	secretboxOut, err = seal(nil, &key, userpassScrypt)
 	return
 }
+func decrypt(masterpass string, salt, encrypted []byte, masterparams ScryptParams) (decrypted []byte, err error) {
+	// Regenerate secretbox key from Scrypt hash of masterpassphrase using stored salt
+	key, err := masterKey(context.Background(), masterpass, salt, masterparams)
+	if err != nil {
+		return nil, err
+	}
+	return open(&key, encrypted)
+}
+
+// masterKey derives the 32 byte secretbox key from the masterpassphrase Scrypt hash
+func masterKey(ctx context.Context, masterpass string, salt []byte, masterparams ScryptParams) (key [32]byte, err error) {
+	masterpassScrypt, err := scryptHash(ctx, masterpass, salt, masterparams)
+	if err != nil {
+		return key, err
+	}
+	// Create 32 byte hash of masterpass Scrypt output for Secretbox
+	return blake2b.Sum256(masterpassScrypt), nil
+}
+
+// seal encrypts plaintext with secretbox using a random 24 byte nonce read from random, which is prepended to the output
+func seal(random io.Reader, key *[32]byte, plaintext []byte) ([]byte, error) {
+	b, err := randBytes(random, 24)
+	if err != nil {
+		return nil, err
+	}
+	var nonce [24]byte
+	copy(nonce[:], b)
+	return secretbox.Seal(nonce[:], plaintext, &nonce, key), nil
+}
+
+// randBytes returns n bytes read from random, or crypto/rand if random is nil, and a *RandError on failure
+func randBytes(random io.Reader, n int) ([]byte, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(random, b); err != nil {
+		return nil, &RandError{Err: err}
+	}
+	return b, nil
+}
+
+// open decrypts secretbox output with the nonce stored in the first 24 bytes
+func open(key *[32]byte, encrypted []byte) ([]byte, error) {
+	if len(encrypted) < 24+secretbox.Overhead {
+		return nil, ErrCiphertextFormat
+	}
+	var decryptNonce [24]byte
+	copy(decryptNonce[:], encrypted[:24])
+	decrypted, ok := secretbox.Open(nil, encrypted[24:], &decryptNonce, key)
+	if !ok {
+		return nil, ErrSecretBoxDecryptFail
+	}
+	return decrypted, nil
+}
 func verifyV1(userpass, masterpass string, parts []string) (err error) {
 	if len(parts) != 10 {
 		return ErrCiphertextFormat
@@ -203,7 +440,17 @@ func verifyV1(userpass, masterpass string, parts []string) (err error) {
 	// Regenerate Blake2b-256 hash (32 bytes) using masterpass for secretbox
 	//masterpassHash := blake2b.Sum256([]byte(masterpass))
 	salt, err := base64.StdEncoding.DecodeString(parts[3])
-	masterpassScrypt, err := scryptHash(masterpass, salt, masterparams)
+	if err != nil {
+		return &FormatError{Field: "master salt", Index: 3, Cause: err}
+	}
+	encrypted, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return &FormatError{Field: "ciphertext", Index: 2, Cause: err}
+	}
+	if _, _, err = splitEncrypted(CipherSecretbox, encrypted); err != nil {
+		return &FormatError{Field: "ciphertext", Index: 2, Cause: err}
+	}
+	masterpassScrypt, err := scryptHash(context.Background(), masterpass, salt, masterparams)
 	if err != nil {
 		return err
 	}
@@ -213,10 +460,6 @@ func verifyV1(userpass, masterpass string, parts []string) (err error) {
 	// encrypt the message. One way to achieve this is to store the nonce
 	// alongside the encrypted message. Above, we stored the nonce in the first
 	// 24 bytes of the encrypted text.
-	encrypted, err := base64.StdEncoding.DecodeString(parts[2])
-	if err != nil {
-		return err
-	}
 	var decryptNonce [24]byte
 	copy(decryptNonce[:], encrypted[:24])
 	decrypted, ok := secretbox.Open(nil, encrypted[24:], &decryptNonce, &mpScryptB2)
@@ -227,7 +470,7 @@ func verifyV1(userpass, masterpass string, parts []string) (err error) {
 	// Use scrypt to derive key for comparison
 	// The plaintext password is transformed into a hash value using Blake2b-512
 	userPwBlake := blake2b.Sum512([]byte(userpass))
-	userpassScrypt, err := scryptHash(hex.EncodeToString(userPwBlake[:]), []byte(decrypted[56:]), userparams)
+	userpassScrypt, err := scryptHash(context.Background(), hex.EncodeToString(userPwBlake[:]), []byte(decrypted[56:]), userparams)
 	if err != nil {
 		return err
 	}
@@ -240,31 +483,60 @@ func verifyV1(userpass, masterpass string, parts []string) (err error) {
 
 	return err
 }
+func verifyV2(userpass, masterpass string, parts []string) (err error) {
+	if len(parts) != 9 {
+		return ErrCiphertextFormat
+	}
+	if parts[0] != "secBoxv2" {
+		return ErrCiphertextVer
+	}
+	// Extract KDF parameters from string
+	userparams, masterparams, err := getParamsV2(parts)
+	if err != nil {
+		return err
+	}
+	salt, err := base64.StdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return &FormatError{Field: "master salt", Index: 5, Cause: err}
+	}
+	encrypted, err := base64.StdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return &FormatError{Field: "ciphertext", Index: 4, Cause: err}
+	}
+	decrypted, err := decrypt(masterpass, salt, encrypted, masterparams)
+	if err != nil {
+		return err
+	}
+	// Derive user passphrase hash using stored KDF salt and compare to decrypted hash
+	return userparams.verify(context.Background(), userpass, decrypted)
+}
+func verifyV3(userpass, masterpass, ciphertext string) (err error) {
+	return VerifyContext(context.Background(), userpass, masterpass, ciphertext)
+}
 func validateParams(p ScryptParams) error {
 	// Cost factor must be multiple of 2
 	if p.N < 4096 || p.N > 600000 {
-		return ErrScryptParamN
+		return &ParamError{Param: "N", Value: int64(p.N), Min: 4096, Max: 600000, Err: ErrScryptParamN}
 	}
 	if p.R < 4 || p.R > 128 {
-		return ErrScryptParamR
+		return &ParamError{Param: "R", Value: int64(p.R), Min: 4, Max: 128, Err: ErrScryptParamR}
 	}
 	if p.P < 1 || p.P > 20 {
-		return ErrScryptParamP
+		return &ParamError{Param: "P", Value: int64(p.P), Min: 1, Max: 20, Err: ErrScryptParamP}
 	}
 	return nil
 }
 
-func scryptHash(p string, salt []byte, params ScryptParams) (hash []byte, err error) {
-	if salt == nil {
-		salt = make([]byte, 8)
-		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
-			panic("rand salt failure")
-		}
-	}
+func scryptHash(ctx context.Context, p string, salt []byte, params ScryptParams) (hash []byte, err error) {
 	err = validateParams(params)
 	if err != nil {
 		return nil, err
 	}
+	release, err := admit(ctx, scryptMemory(params))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 	// 1) The plaintext password is transformed into a hash value using Blake2b
 	hashedPass := blake2b.Sum512([]byte(p))
 
@@ -280,38 +552,21 @@ func scryptHash(p string, salt []byte, params ScryptParams) (hash []byte, err er
 }
 func getParams(parts []string) (userparams, masterparams ScryptParams, err error) {
 	// Get Scrypt parameters
-	userparams.N, err = strconv.Atoi(parts[4])
-	if err != nil {
-		return
-	}
-	userparams.R, err = strconv.Atoi(parts[5])
-	if err != nil {
-		return
-	}
-	userparams.P, err = strconv.Atoi(parts[6])
-	if err != nil {
-		return
-	}
-	err = validateParams(userparams)
+	userparams, err = parseScryptFields(parts[4:7], 4, LayerUser)
 	if err != nil {
 		return
 	}
-	masterparams.N, err = strconv.Atoi(parts[7])
-	if err != nil {
-		return
-	}
-	masterparams.R, err = strconv.Atoi(parts[8])
-	if err != nil {
-		return
-	}
-	masterparams.P, err = strconv.Atoi(parts[9])
-	if err != nil {
-		return
-	}
-	err = validateParams(masterparams)
+	masterparams, err = parseScryptFields(parts[7:10], 7, LayerMaster)
+	return
+}
+func getParamsV2(parts []string) (userparams UserParams, masterparams ScryptParams, err error) {
+	// Get user KDF parameters
+	userparams, err = parseUserFields(parts[2:4], 2)
 	if err != nil {
 		return
 	}
+	// Get master Scrypt parameters
+	masterparams, err = parseScryptFields(parts[6:9], 6, LayerMaster)
 	return
 }
 
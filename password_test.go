@@ -18,7 +18,9 @@
 package password
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/icrowley/fake"
@@ -42,7 +44,7 @@ func TestBench(t *testing.T) {
 
 	// Test Bench hash error
 	result, err := Benchmark(ScryptParams{N: 2048, R: 8, P: 1})
-	if err != ErrScryptParamN && result != 0 {
+	if !errors.Is(err, ErrScryptParamN) && result != 0 {
 		t.Log(err)
 		t.FailNow()
 	}
@@ -63,7 +65,7 @@ func TestHash(t *testing.T) {
 			t.Log(err)
 			t.FailNow()
 		}
-		t.Logf("Output: " + output)
+		t.Logf("Output: %s", output)
 
 		// Check Output Length
 		lgth := len(output)
@@ -82,12 +84,12 @@ func TestHash(t *testing.T) {
 
 	// Test with Bad Params
 	_, err := Hash("password1234", "masterpassphrase", 0, ScryptParams{N: 2048, R: 16, P: 1}, DefaultParams)
-	if err != ErrScryptParamN {
+	if !errors.Is(err, ErrScryptParamN) {
 		t.Log("Expected Scrypt N failure for user params")
 		t.FailNow()
 	}
 	_, err = Hash("password1234", "masterpassphrase", 0, DefaultParams, ScryptParams{N: 2048, R: 16, P: 1})
-	if err != ErrScryptParamN {
+	if !errors.Is(err, ErrScryptParamN) {
 		t.Log("Expected Scrypt N failure for master params")
 		t.FailNow()
 	}
@@ -107,6 +109,15 @@ func TestGetHashVersion(t *testing.T) {
 		t.Log("Expected int parse from string error")
 		t.FailNow()
 	}
+
+	// Identifiers are matched whole, not trimmed down to a number
+	for _, id := range []string{"v1", "xsecBoxv1", "secBoxv7"} {
+		hash := strings.Replace(testV1Hash, "secBoxv1", id, 1)
+		if _, err = GetHashVersion(hash); !errors.Is(err, ErrCiphertextVer) {
+			t.Logf("%v: expected ErrCiphertextVer, got %v", id, err)
+			t.FailNow()
+		}
+	}
 }
 
 func TestGetMasterVersion(t *testing.T) {
@@ -134,7 +145,7 @@ func TestGetParamsFromHash(t *testing.T) {
 
 	// Test with Bad Format
 	user, master, err = GetParams("secBoxv1$5DxIID0p4uz073qNngNsxYhXKPJITbjdvpjLju/XKbbzKDjdXVvgCSVbNIjCAg2QvA8O4mC+/fZpExJJx9rVpgxeL4xH16kN5/AGHtaa3kPNlP0tB5dJjDbFsJVr7u/ar9v4hzwQYhk=$xGvsvszfJDY=$32768$16$1$16384$8$1")
-	if err != ErrCiphertextFormat {
+	if !errors.Is(err, ErrCiphertextFormat) {
 		t.Log("Expected invalid format error")
 		t.FailNow()
 	}
@@ -206,7 +217,7 @@ func TestUpdateMaster(t *testing.T) {
 
 	// Test Bad Params Fail
 	_, err = UpdateMaster("masterpassphrase2", "masterpassphrase", 1, "secBoxv1$0$l8W69jygGur7sa0669mAJnIuYgjsbkx4wd+RdDzwIn2Z49FJurWkJDx2NA8g+ED9Nn6vGCLNFoHXSDIDeDBvJXouxs5zyX6mVozceVAVO7IadrL4+KKohV3MzoVlgodUYeNToOVB/5A=$4LZVjQ8P9pA=$32768$16$1$16384$8$1", ScryptParams{N: 2048, R: 8, P: 1})
-	if err != ErrScryptParamN {
+	if !errors.Is(err, ErrScryptParamN) {
 		t.Log(err)
 		t.Log("Expected Scrypt N param failure")
 		t.FailNow()
@@ -386,3 +397,156 @@ func TestValidateParams(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestHashV2(t *testing.T) {
+	for _, userparams := range []UserParams{testArgon2Params, DefaultParams} {
+		output, err := HashV2("password1234", "masterpassphrase", 0, userparams, DefaultParams)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		t.Logf("Output: %s", output)
+
+		if v, err := GetHashVersion(output); err != nil || v != 2 {
+			t.Log("Expected hash version 2")
+			t.FailNow()
+		}
+		if err := Verify("password1234", "masterpassphrase", output); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if err := Verify("passw0rd1234", "masterpassphrase", output); err != ErrPassphraseHashMismatch {
+			t.Log("Expected passphrase mismatch")
+			t.FailNow()
+		}
+		if err := Verify("password1234", "mast3rpassphrase", output); err != ErrSecretBoxDecryptFail {
+			t.Log("Expected decryption failure")
+			t.FailNow()
+		}
+
+		user, master, err := GetKDFParams(output)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if user != userparams || master != DefaultParams {
+			t.Log("Returned parameters do not match given")
+			t.FailNow()
+		}
+	}
+
+	// Test with Bad Params
+	_, err := HashV2("password1234", "masterpassphrase", 0, Argon2Params{Time: 0, Memory: 65536, Threads: 4, KeyLen: 32}, DefaultParams)
+	if !errors.Is(err, ErrArgon2ParamTime) {
+		t.Log("Expected Argon2 time failure for user params")
+		t.FailNow()
+	}
+	_, err = HashV2("password1234", "masterpassphrase", 0, nil, DefaultParams)
+	if !errors.Is(err, ErrUnsupportedKDF) {
+		t.Log("Expected unsupported KDF failure")
+		t.FailNow()
+	}
+	_, err = HashV2("pass", "masterpassphrase", 0, testArgon2Params, DefaultParams)
+	if err != ErrPassphraseLength {
+		t.Log("Expected Passphrase length failure")
+		t.FailNow()
+	}
+}
+
+func TestGetParamsV2(t *testing.T) {
+	output, err := HashV2("password1234", "masterpassphrase", 0, testArgon2Params, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	// Argon2id user params can not be returned as ScryptParams
+	_, _, err = GetParams(output)
+	if !errors.Is(err, ErrUnsupportedKDF) {
+		t.Log("Expected unsupported KDF failure")
+		t.FailNow()
+	}
+
+	output, err = HashV2("password1234", "masterpassphrase", 0, ScryptParams{N: 32768, R: 16, P: 1}, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	user, master, err := GetParams(output)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if user.N != 32768 || user.R != 16 || user.P != 1 || master != DefaultParams {
+		t.Log("Returned parameters do not match given")
+		t.FailNow()
+	}
+}
+
+func TestUpdateMasterV2(t *testing.T) {
+	output, err := HashV2("password1234", "masterpassphrase", 0, testArgon2Params, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	updated, err := UpdateMaster("masterpassphrase2", "masterpassphrase", 1, output, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if v, err := GetMasterVersion(updated); err != nil || v != 1 {
+		t.Log("Expected master version 1")
+		t.FailNow()
+	}
+	err = Verify("password1234", "masterpassphrase2", updated)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	// Test Bad Version Fail
+	_, err = UpdateMaster("masterpassphrase3", "masterpassphrase2", 1, updated, DefaultParams)
+	if err != ErrInvalidVersionUpdate {
+		t.Log("Expected Invalid Version update error")
+		t.FailNow()
+	}
+	// Test Bad Old Master passphrase, decrypt fail
+	_, err = UpdateMaster("masterpassphrase3", "masterpassphrase", 2, updated, DefaultParams)
+	if err != ErrSecretBoxDecryptFail {
+		t.Log("Expected decryption failure")
+		t.FailNow()
+	}
+}
+
+func TestVerifyV2(t *testing.T) {
+	output, err := HashV2("password1234", "masterpassphrase", 0, testArgon2Params, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	parts := strings.Split(output, "$")
+	// Fail Length
+	if err := verifyV2("password1234", "masterpassphrase", parts[1:]); err != ErrCiphertextFormat {
+		t.Log("Expected Format Failure")
+		t.FailNow()
+	}
+	// Fail ciphertext version check
+	bad := append([]string{"secBoxv1"}, parts[1:]...)
+	if err := verifyV2("password1234", "masterpassphrase", bad); err != ErrCiphertextVer {
+		t.Log("Expect Version Failure")
+		t.FailNow()
+	}
+	// Fail truncated ciphertext
+	bad = append([]string{}, parts...)
+	bad[4] = "AAAA"
+	if err := verifyV2("password1234", "masterpassphrase", bad); err != ErrCiphertextFormat {
+		t.Log("Expected Format Failure")
+		t.FailNow()
+	}
+	// Fail unknown KDF
+	bad = append([]string{}, parts...)
+	bad[2] = "md5crypt"
+	if err := verifyV2("password1234", "masterpassphrase", bad); !errors.Is(err, ErrUnsupportedKDF) {
+		t.Log("Expected unsupported KDF failure")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,129 @@
+package password
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// PasswordHash holds a hash validated by ParseHash, for storing hashes in databases and JSON or text encoded
+// configuration so that malformed hashes are rejected when loaded rather than at login. It implements sql.Scanner,
+// driver.Valuer, encoding.TextMarshaler, encoding.TextUnmarshaler, json.Marshaler and json.Unmarshaler. The zero
+// value holds no hash and is stored as SQL NULL and JSON null.
+type PasswordHash struct {
+	ciphertext string
+	parsed     *ParsedHash
+}
+
+// NewPasswordHash takes ciphertext string in any format accepted by ParseHash and returns a PasswordHash and error
+func NewPasswordHash(ciphertext string) (PasswordHash, error) {
+	parsed, err := ParseHash(ciphertext)
+	if err != nil {
+		return PasswordHash{}, err
+	}
+	return PasswordHash{ciphertext: ciphertext, parsed: parsed}, nil
+}
+
+// String returns the hash as given to NewPasswordHash or loaded, or an empty string for the zero value
+func (h PasswordHash) String() string {
+	return h.ciphertext
+}
+
+// IsZero reports whether h holds no hash
+func (h PasswordHash) IsZero() bool {
+	return h.parsed == nil
+}
+
+// Verify takes passphrase and masterpassphrase as strings and returns error if verification fails, else returns nil upon success
+func (h PasswordHash) Verify(userpass, masterpass string) error {
+	if h.parsed == nil {
+		return ErrCiphertextFormat
+	}
+	return Verify(userpass, masterpass, h.ciphertext)
+}
+
+// MasterVersion returns the master passphrase version of the hash, 0 for the zero value
+func (h PasswordHash) MasterVersion() int {
+	if h.parsed == nil {
+		return 0
+	}
+	return h.parsed.MasterVersion
+}
+
+// Params returns the user KDF parameters as ScryptParams or Argon2Params and master parameters of the hash,
+// nil user parameters for the zero value
+func (h PasswordHash) Params() (userParams UserParams, masterParams ScryptParams) {
+	if h.parsed == nil {
+		return nil, masterParams
+	}
+	return h.parsed.UserParams, h.parsed.MasterParams
+}
+
+// Scan implements sql.Scanner for string and []byte columns, NULL scans to the zero value and an empty string is
+// rejected as malformed
+func (h *PasswordHash) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*h = PasswordHash{}
+		return nil
+	case string:
+		return h.set(v)
+	case []byte:
+		return h.set(string(v))
+	}
+	return fmt.Errorf("Cannot scan %T into PasswordHash", src)
+}
+
+// Value implements driver.Valuer, the zero value is stored as NULL
+func (h PasswordHash) Value() (driver.Value, error) {
+	if h.parsed == nil {
+		return nil, nil
+	}
+	return h.ciphertext, nil
+}
+
+// MarshalText implements encoding.TextMarshaler, the zero value is encoded as empty text
+func (h PasswordHash) MarshalText() ([]byte, error) {
+	return []byte(h.ciphertext), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, empty text unmarshals to the zero value as text has no null
+func (h *PasswordHash) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*h = PasswordHash{}
+		return nil
+	}
+	return h.set(string(text))
+}
+
+// MarshalJSON implements json.Marshaler, the zero value is encoded as null
+func (h PasswordHash) MarshalJSON() ([]byte, error) {
+	if h.parsed == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(h.ciphertext)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, null unmarshals to the zero value
+func (h *PasswordHash) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*h = PasswordHash{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return h.set(s)
+}
+
+// set validates and stores ciphertext, h is unchanged on error. An empty string is an error like any other malformed
+// hash, callers handle their encoding's null first.
+func (h *PasswordHash) set(ciphertext string) error {
+	parsed, err := NewPasswordHash(ciphertext)
+	if err != nil {
+		return err
+	}
+	*h = parsed
+	return nil
+}
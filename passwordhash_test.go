@@ -0,0 +1,113 @@
+package password
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+var (
+	_ sql.Scanner              = (*PasswordHash)(nil)
+	_ driver.Valuer            = PasswordHash{}
+	_ encoding.TextMarshaler   = PasswordHash{}
+	_ encoding.TextUnmarshaler = (*PasswordHash)(nil)
+	_ json.Marshaler           = PasswordHash{}
+	_ json.Unmarshaler         = (*PasswordHash)(nil)
+)
+
+func TestPasswordHash(t *testing.T) {
+	output, err := HashV2("password1234", "masterpassphrase", 2, testArgon2Params, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	var h PasswordHash
+	if err = h.Scan([]byte(output)); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err = h.Verify("password1234", "masterpassphrase"); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if h.MasterVersion() != 2 {
+		t.Log("Unexpected master version")
+		t.FailNow()
+	}
+	if user, master := h.Params(); user != testArgon2Params || master != DefaultParams {
+		t.Log("Unexpected parameters")
+		t.FailNow()
+	}
+	if v, err := h.Value(); err != nil || v != output {
+		t.Log("Expected Value to return the hash")
+		t.FailNow()
+	}
+
+	type account struct {
+		Name string
+		Hash PasswordHash
+	}
+	data, err := json.Marshal(account{Name: "a", Hash: h})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	var a account
+	if err = json.Unmarshal(data, &a); err != nil || a.Hash.String() != output {
+		t.Log("Expected JSON round trip")
+		t.FailNow()
+	}
+	if err = json.Unmarshal([]byte(`{"Name":"a","Hash":"secBoxv2$0"}`), &a); !errors.Is(err, ErrCiphertextFormat) {
+		t.Logf("Expected format error, got %v", err)
+		t.FailNow()
+	}
+	if err = a.Hash.UnmarshalText([]byte(testV1Hash)); err != nil || a.Hash.MasterVersion() != 0 {
+		t.Log("Expected text to unmarshal")
+		t.FailNow()
+	}
+
+	// Malformed rows are rejected at scan time and leave the value unchanged
+	if err = h.Scan("secBoxv1$x"); !errors.Is(err, ErrCiphertextFormat) || h.String() != output {
+		t.Log("Expected format error")
+		t.FailNow()
+	}
+	if err = h.Scan(""); !errors.Is(err, ErrCiphertextFormat) || h.IsZero() {
+		t.Log("Expected empty string to be rejected")
+		t.FailNow()
+	}
+	if err = h.Scan(42); err == nil {
+		t.Log("Expected error scanning int")
+		t.FailNow()
+	}
+	if err = h.Scan(nil); err != nil || !h.IsZero() {
+		t.Log("Expected NULL to scan to zero value")
+		t.FailNow()
+	}
+	if v, err := h.Value(); err != nil || v != nil {
+		t.Log("Expected zero value to be NULL")
+		t.FailNow()
+	}
+	if data, err := json.Marshal(h); err != nil || string(data) != "null" {
+		t.Log("Expected zero value to be null")
+		t.FailNow()
+	}
+	if err = h.Verify("password1234", "masterpassphrase"); err != ErrCiphertextFormat {
+		t.Log("Expected zero value to fail verification")
+		t.FailNow()
+	}
+
+	// The zero value round trips through text, which has no null
+	text, err := h.MarshalText()
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	a.Hash, _ = NewPasswordHash(output)
+	if err = a.Hash.UnmarshalText(text); err != nil || !a.Hash.IsZero() {
+		t.Logf("Expected zero value text round trip, got %v", err)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,99 @@
+package password
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"fmt"
+	"hash"
+	"io"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// KDFPBKDF2SHA256 identifies the PBKDF2-HMAC-SHA256 user passphrase KDF in secBoxv2 hashes
+	KDFPBKDF2SHA256 = "pbkdf2-sha256"
+	// KDFPBKDF2SHA512 identifies the PBKDF2-HMAC-SHA512 user passphrase KDF in secBoxv2 hashes
+	KDFPBKDF2SHA512 = "pbkdf2-sha512"
+)
+
+// DefaultPBKDF2Params defines PBKDF2-HMAC-SHA256 Parameters, per the OWASP recommendation of 600,000 iterations
+var DefaultPBKDF2Params = PBKDF2Params{Hash: "sha256", Iterations: 600000, KeyLen: 32}
+
+// PBKDF2Params sets the PBKDF2 derivation parameters used for hashing, for deployments requiring a FIPS 140 approved
+// user passphrase KDF. Hash is "sha256" or "sha512". The user passphrase is not prehashed with Blake2b and a 16 byte
+// salt is used. The master layer is unchanged, pair it with CipherAES256GCM to avoid XSalsa20-Poly1305.
+type PBKDF2Params struct {
+	Hash       string
+	Iterations int
+	KeyLen     int
+}
+
+// KDF returns "pbkdf2-sha256" or "pbkdf2-sha512"
+func (p PBKDF2Params) KDF() string { return "pbkdf2-" + p.Hash }
+
+func (p PBKDF2Params) encode() string {
+	return fmt.Sprintf("i=%v,l=%v", p.Iterations, p.KeyLen)
+}
+
+func (p PBKDF2Params) size() int { return p.KeyLen + 16 }
+
+func (p PBKDF2Params) validate() error {
+	if p.hashFunc() == nil {
+		return ErrUnsupportedKDF
+	}
+	if p.Iterations < 10000 || p.Iterations > 100000000 {
+		return &ParamError{Param: "Iterations", Value: int64(p.Iterations), Min: 10000, Max: 100000000, Err: ErrPBKDF2ParamIterations}
+	}
+	if p.KeyLen < 16 || p.KeyLen > 64 {
+		return &ParamError{Param: "KeyLen", Value: int64(p.KeyLen), Min: 16, Max: 64, Err: ErrPBKDF2ParamKeyLen}
+	}
+	return nil
+}
+
+func (p PBKDF2Params) hashFunc() func() hash.Hash {
+	switch p.Hash {
+	case "sha256":
+		return sha256.New
+	case "sha512":
+		return sha512.New
+	}
+	return nil
+}
+
+func (p PBKDF2Params) hash(ctx context.Context, random io.Reader, userpass string) ([]byte, error) {
+	salt, err := randBytes(random, 16)
+	if err != nil {
+		return nil, err
+	}
+	return pbkdf2Hash(userpass, salt, p)
+}
+
+func (p PBKDF2Params) verify(ctx context.Context, userpass string, decrypted []byte) error {
+	if len(decrypted) != p.KeyLen+16 {
+		return ErrCiphertextFormat
+	}
+	userpassPBKDF2, err := pbkdf2Hash(userpass, decrypted[p.KeyLen:], p)
+	if err != nil {
+		return err
+	}
+	if res := subtle.ConstantTimeCompare(decrypted, userpassPBKDF2); res != 1 {
+		return ErrPassphraseHashMismatch
+	}
+	return nil
+}
+
+func pbkdf2Hash(userpass string, salt []byte, params PBKDF2Params) ([]byte, error) {
+	err := params.validate()
+	if err != nil {
+		return nil, err
+	}
+	// The plaintext password is hashed with PBKDF2 using supplied 16 byte salt, with salt appended to output
+	key := pbkdf2.Key([]byte(userpass), salt, params.Iterations, params.KeyLen, params.hashFunc())
+	output := make([]byte, len(key)+len(salt))
+	copy(output, key)
+	copy(output[len(key):], salt)
+	return output, nil
+}
@@ -0,0 +1,51 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPBKDF2(t *testing.T) {
+	for _, params := range []PBKDF2Params{{Hash: "sha256", Iterations: 10000, KeyLen: 32}, {Hash: "sha512", Iterations: 10000, KeyLen: 64}} {
+		output, err := HashV3("password1234", "masterpassphrase", 0, CipherAES256GCM, params, DefaultParams)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if !strings.Contains(output, "$pbkdf2-"+params.Hash+"$i=10000,l=") {
+			t.Logf("Unexpected output: %s", output)
+			t.FailNow()
+		}
+		if err = Verify("password1234", "masterpassphrase", output); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if err = Verify("passw0rd1234", "masterpassphrase", output); err != ErrPassphraseHashMismatch {
+			t.Log("Expected passphrase mismatch")
+			t.FailNow()
+		}
+		parsed, err := ParseHash(output)
+		if err != nil || parsed.UserParams != params {
+			t.Log("Expected parsed PBKDF2 parameters", err)
+			t.FailNow()
+		}
+	}
+
+	if _, err := HashV2("password1234", "masterpassphrase", 0, PBKDF2Params{Hash: "sha256", Iterations: 1000, KeyLen: 32}, DefaultParams); !errors.Is(err, ErrPBKDF2ParamIterations) {
+		t.Log("Expected ErrPBKDF2ParamIterations")
+		t.FailNow()
+	}
+	if _, err := HashV2("password1234", "masterpassphrase", 0, PBKDF2Params{Hash: "sha256", Iterations: 10000, KeyLen: 8}, DefaultParams); !errors.Is(err, ErrPBKDF2ParamKeyLen) {
+		t.Log("Expected ErrPBKDF2ParamKeyLen")
+		t.FailNow()
+	}
+	if _, err := HashV2("password1234", "masterpassphrase", 0, PBKDF2Params{Hash: "md5", Iterations: 10000, KeyLen: 32}, DefaultParams); err != ErrUnsupportedKDF {
+		t.Log("Expected ErrUnsupportedKDF")
+		t.FailNow()
+	}
+	if _, err := ParseUserParams(KDFPBKDF2SHA256, "i=10000,l=32,x=1"); err != ErrCiphertextFormat {
+		t.Log("Expected ErrCiphertextFormat")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,133 @@
+package password
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// phcID is the PHC string format function identifier for goSecretBoxPassword hashes
+const phcID = "secbox"
+
+// phcEncoding is the PHC string format B64 encoding, standard base64 without padding
+var phcEncoding = base64.RawStdEncoding.Strict()
+
+// EncodePHC takes ciphertext string in secBoxv1, secBoxv2 or secBoxv3 format and returns the same hash in Password Hashing
+// Competition string format and error, ex. "$secbox$v=2$mv=0,kdf=argon2id,um=65536,ut=3,up=4,ul=32,mn=16384,mr=8,mp=1$<salt>$<ciphertext>".
+// User KDF parameters are prefixed with "u" and master Scrypt parameters with "m". DecodePHC reverses the encoding exactly.
+func EncodePHC(ciphertext string) (string, error) {
+	parsed, err := ParseHash(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	// Only canonically encoded input is accepted so that the round trip is exact
+	if !strings.HasPrefix(ciphertext, "$") && parsed.String() != ciphertext {
+		return "", &FormatError{Field: "encoding", Index: -1, Cause: errNotCanonical}
+	}
+	return parsed.PHC(), nil
+}
+
+// DecodePHC takes a hash in PHC string format as produced by EncodePHC and returns it in secBoxv1, secBoxv2 or secBoxv3 format and error
+func DecodePHC(phc string) (string, error) {
+	parsed, err := parsePHC(phc)
+	if err != nil {
+		return "", err
+	}
+	return parsed.String(), nil
+}
+
+// PHC formats the hash fields as PHC string, see EncodePHC
+func (h *ParsedHash) PHC() string {
+	params := []string{fmt.Sprintf("mv=%v", h.MasterVersion)}
+	if h.Version > 2 {
+		params = append(params, "cipher="+h.Cipher)
+	}
+	if h.Version > 1 {
+		params = append(params, "kdf="+h.UserParams.KDF())
+	}
+	for _, kv := range strings.Split(h.UserParams.encode(), ",") {
+		params = append(params, "u"+kv)
+	}
+	m := h.MasterParams
+	params = append(params, fmt.Sprintf("mn=%v", m.N), fmt.Sprintf("mr=%v", m.R), fmt.Sprintf("mp=%v", m.P))
+	return fmt.Sprintf("$%s$v=%v$%s$%s$%s", phcID, h.Version, strings.Join(params, ","), phcEncoding.EncodeToString(h.MasterSalt), phcEncoding.EncodeToString(h.encrypted()))
+}
+
+// parsePHC takes a hash in PHC string format and returns its decoded fields. Only the exact output of PHC is accepted.
+func parsePHC(s string) (h *ParsedHash, err error) {
+	parts := strings.Split(s, "$")
+	if len(parts) != 6 || parts[0] != "" {
+		return nil, fieldCountError(6, len(parts))
+	}
+	if parts[1] != phcID {
+		return nil, &FormatError{Field: "identifier", Index: 1, Cause: ErrCiphertextVer}
+	}
+	h = &ParsedHash{Cipher: CipherSecretbox}
+	switch parts[2] {
+	case "v=1":
+		h.Version = 1
+	case "v=2":
+		h.Version = 2
+	case "v=3":
+		h.Version = 3
+	default:
+		return nil, &FormatError{Field: "version", Index: 2, Cause: ErrCiphertextVer}
+	}
+	paramErr := func(err error) error {
+		return &FormatError{Field: "params", Index: 3, Cause: err}
+	}
+	values, err := parseParamList(parts[3])
+	if err != nil {
+		return nil, paramErr(err)
+	}
+	if h.MasterVersion, err = paramInt(values, "mv"); err != nil {
+		return nil, paramErr(err)
+	}
+	if h.MasterParams.N, err = paramInt(values, "mn"); err != nil {
+		return nil, paramErr(err)
+	}
+	if h.MasterParams.R, err = paramInt(values, "mr"); err != nil {
+		return nil, paramErr(err)
+	}
+	if h.MasterParams.P, err = paramInt(values, "mp"); err != nil {
+		return nil, paramErr(err)
+	}
+	if err = validateMaster(h.MasterParams); err != nil {
+		return nil, paramErr(err)
+	}
+	if h.Version > 2 {
+		if h.Cipher = values["cipher"]; validateCipher(h.Cipher) != nil {
+			return nil, paramErr(ErrUnsupportedCipher)
+		}
+	}
+	kdf := KDFScrypt
+	if h.Version > 1 {
+		if kdf = values["kdf"]; kdf == "" {
+			return nil, paramErr(ErrUnsupportedKDF)
+		}
+	}
+	var user []string
+	for k, v := range values {
+		if strings.HasPrefix(k, "u") {
+			user = append(user, k[1:]+"="+v)
+		}
+	}
+	if h.UserParams, err = ParseUserParams(kdf, strings.Join(user, ",")); err != nil {
+		return nil, paramErr(withLayer(err, LayerUser))
+	}
+	if h.MasterSalt, err = phcEncoding.DecodeString(parts[4]); err != nil {
+		return nil, &FormatError{Field: "master salt", Index: 4, Cause: err}
+	}
+	encrypted, err := phcEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, &FormatError{Field: "ciphertext", Index: 5, Cause: err}
+	}
+	if h.Nonce, h.Box, err = splitEncrypted(h.Cipher, encrypted); err != nil {
+		return nil, &FormatError{Field: "ciphertext", Index: 5, Cause: err}
+	}
+	// Reject unknown or reordered parameters and any other non-canonical encoding
+	if h.PHC() != s {
+		return nil, &FormatError{Field: "encoding", Index: -1, Cause: errNotCanonical}
+	}
+	return h, nil
+}
@@ -0,0 +1,118 @@
+package password
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testV1Hash = "secBoxv1$0$Qk09Tgzi2w+z9mtPiwe6uLWPXMY8WQyI3oC7Sqz11PMcRzvqrOhd70fdBXEUmOeM91z2MytB9Lt4VQzjOs21KTYqMx9FwUR2qDa38fmQhT6pLOJCaptpMzgYLC1fvbq4suuW9XpB7RE=$2ZVcHyy/p9Q=$32768$16$1$16384$8$1"
+
+func TestPHCRoundTrip(t *testing.T) {
+	v2, err := HashV2("password1234", "masterpassphrase", 3, testArgon2Params, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	v2Scrypt, err := HashV2("password1234", "masterpassphrase", 0, DefaultParams, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	for _, h := range []string{testV1Hash, v2, v2Scrypt} {
+		phc, err := EncodePHC(h)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		t.Logf("PHC: %s", phc)
+		if !strings.HasPrefix(phc, "$secbox$v=") {
+			t.Log("Expected PHC identifier")
+			t.FailNow()
+		}
+		decoded, err := DecodePHC(phc)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if decoded != h {
+			t.Logf("Round trip mismatch: %s != %s", decoded, h)
+			t.FailNow()
+		}
+		if err := Verify("password1234", "masterpassphrase", phc); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		// The helpers taking a hash accept PHC strings too
+		if v, err := GetHashVersion(phc); err != nil || !strings.HasPrefix(h, "secBoxv"+strconv.Itoa(v)+"$") {
+			t.Logf("Unexpected version %v: %v", v, err)
+			t.FailNow()
+		}
+		if _, _, err := GetParams(phc); err != nil && err != ErrUnsupportedKDF {
+			t.Log(err)
+			t.FailNow()
+		}
+		updated, err := UpdateMaster("masterpassphrase2", "masterpassphrase", 4, phc, DefaultParams)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if err := Verify("password1234", "masterpassphrase2", updated); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+	}
+
+	phc, _ := EncodePHC(v2)
+	want := "$secbox$v=2$mv=3,kdf=argon2id,um=8192,ut=1,up=2,ul=32,mn=16384,mr=8,mp=1$"
+	if !strings.HasPrefix(phc, want) {
+		t.Logf("Expected prefix %s, got %s", want, phc)
+		t.FailNow()
+	}
+	// PHC input is accepted by Hasher and Keyring through parseHash
+	h, err := NewHasher("masterpassphrase", 3, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err := h.Verify("password1234", phc); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+}
+
+func TestPHCStrict(t *testing.T) {
+	phc, err := EncodePHC(testV1Hash)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	parts := strings.Split(phc, "$")
+	bad := []string{
+		"",
+		"$secbox$v=1",
+		strings.Replace(phc, "$secbox$", "$scrypt$", 1),
+		strings.Replace(phc, "v=1", "v=3", 1),
+		strings.Replace(phc, "mv=0,", "", 1),
+		strings.Replace(phc, "mv=0,", "mv=00,", 1),
+		strings.Replace(phc, "mv=0,", "mv=0,kdf=scrypt,", 1),
+		strings.Replace(phc, "mv=0,un=32768", "un=32768,mv=0", 1),
+		strings.Replace(phc, "mp=1", "mp=1,x=1", 1),
+		strings.Replace(phc, "un=32768", "un=2048", 1),
+		strings.Join(append(append([]string{}, parts[:4]...), parts[4]+"=", parts[5]), "$"),
+		strings.Join(append(append([]string{}, parts[:5]...), parts[5]+"!"), "$"),
+	}
+	for _, b := range bad {
+		if _, err := DecodePHC(b); err == nil {
+			t.Logf("Expected decode failure for %s", b)
+			t.FailNow()
+		}
+	}
+
+	// Non canonical native input can not round trip
+	if _, err := EncodePHC(strings.Replace(testV1Hash, "$32768$", "$032768$", 1)); !errors.Is(err, ErrCiphertextFormat) {
+		t.Log("Expected Ciphertext format failure")
+		t.FailNow()
+	}
+}
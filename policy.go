@@ -0,0 +1,285 @@
+package password
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Policy rules reported in Violation
+const (
+	RuleMinLength = "min_length"
+	RuleMaxLength = "max_length"
+	RuleUpper     = "upper"
+	RuleLower     = "lower"
+	RuleDigit     = "digit"
+	RuleSymbol    = "symbol"
+	RuleClasses   = "classes"
+	RuleBanned    = "banned"
+	RuleIdentity  = "identity"
+	RuleStrength  = "strength"
+	RuleBreached  = "breached"
+)
+
+// Policy describes the user passphrases an application accepts. Zero value fields are not checked. Lengths are counted
+// in runes rather than bytes, and banned substrings and identities are compared case insensitively.
+type Policy struct {
+	// MinLength is the minimum passphrase length in runes
+	MinLength int
+	// MaxLength is the maximum passphrase length in runes
+	MaxLength int
+	// RequireUpper, RequireLower, RequireDigit and RequireSymbol each require a character of that class, symbols
+	// being Unicode punctuation and symbols
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// MinClasses is the minimum number of the four character classes present, ex. 3 for any three of four
+	MinClasses int
+	// Banned passphrases may not contain any of these substrings, ex. the application name
+	Banned []string
+	// RejectIdentity rejects passphrases containing, contained in or similar to the usernames or emails given to Check
+	RejectIdentity bool
+	// MinScore is the minimum EstimateStrength score from 0 to 4, with the identities given to Check as user inputs.
+	// 3 is a reasonable choice for most applications.
+	MinScore int
+	// Breaches rejects passphrases found in a breached password corpus, ex. an HIBPFile, as required by NIST SP 800-63B.
+	// Lookup errors are returned by Check rather than accepting the passphrase.
+	Breaches BreachChecker
+}
+
+// Violation is a Policy rule a passphrase fails. It never includes the passphrase.
+type Violation struct {
+	// Rule is one of the Rule constants
+	Rule string
+	// Limit is the length, number of classes or score required for the length, classes and strength rules
+	Limit int
+	// Match is the banned substring or identity matched
+	Match string
+	// Count is the number of times the passphrase appears in the breached password corpus
+	Count int64
+	// Strength is the estimate that failed the strength rule, its warning and suggestions can be shown to the user
+	Strength *Strength
+}
+
+func (v Violation) String() string {
+	switch v.Rule {
+	case RuleMinLength:
+		return fmt.Sprintf("must be at least %v characters", v.Limit)
+	case RuleMaxLength:
+		return fmt.Sprintf("must be at most %v characters", v.Limit)
+	case RuleUpper:
+		return "must contain an upper case letter"
+	case RuleLower:
+		return "must contain a lower case letter"
+	case RuleDigit:
+		return "must contain a digit"
+	case RuleSymbol:
+		return "must contain a symbol"
+	case RuleClasses:
+		return fmt.Sprintf("must contain %v of upper case letters, lower case letters, digits and symbols", v.Limit)
+	case RuleBanned:
+		return fmt.Sprintf("must not contain %q", v.Match)
+	case RuleIdentity:
+		return fmt.Sprintf("must not be similar to %q", v.Match)
+	case RuleStrength:
+		if v.Strength != nil {
+			return fmt.Sprintf("is too easy to guess, scoring %v of at least %v", v.Strength.Score, v.Limit)
+		}
+		return "is too easy to guess"
+	case RuleBreached:
+		return fmt.Sprintf("appears %v times in breached password lists", v.Count)
+	}
+	return v.Rule
+}
+
+// PolicyError lists every Policy rule a passphrase fails. It matches ErrPolicyViolation with errors.Is, and also
+// ErrPassphraseLength if a length rule failed.
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+	return fmt.Sprintf("%v: passphrase %s", ErrPolicyViolation, strings.Join(msgs, ", "))
+}
+
+// Unwrap returns ErrPolicyViolation
+func (e *PolicyError) Unwrap() error { return ErrPolicyViolation }
+
+// Is reports ErrPassphraseLength for length violations
+func (e *PolicyError) Is(target error) bool {
+	if target != ErrPassphraseLength {
+		return false
+	}
+	for _, v := range e.Violations {
+		if v.Rule == RuleMinLength || v.Rule == RuleMaxLength {
+			return true
+		}
+	}
+	return false
+}
+
+// Check takes passphrase as string and the usernames or emails of its user, and returns a *PolicyError listing every
+// rule it fails, or nil. If Breaches is set the lookup runs after the other rules.
+func (p *Policy) Check(userpass string, identities ...string) error {
+	return p.CheckContext(context.Background(), userpass, identities...)
+}
+
+// CheckContext is Check with ctx passed to the Breaches lookup
+func (p *Policy) CheckContext(ctx context.Context, userpass string, identities ...string) error {
+	v := p.Violations(userpass, identities...)
+	if p.Breaches != nil {
+		count, err := p.Breaches.Count(ctx, userpass)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			v = append(v, Violation{Rule: RuleBreached, Count: count})
+		}
+	}
+	if len(v) > 0 {
+		return &PolicyError{Violations: v}
+	}
+	return nil
+}
+
+// clone returns a copy of p so that later changes by the caller have no effect, or nil
+func (p *Policy) clone() *Policy {
+	if p == nil {
+		return nil
+	}
+	copied := *p
+	copied.Banned = append([]string(nil), p.Banned...)
+	return &copied
+}
+
+// Violations takes passphrase as string and the usernames or emails of its user, and returns every rule it fails
+// in the order the fields of Policy are declared. Breaches is only looked up by Check.
+func (p *Policy) Violations(userpass string, identities ...string) []Violation {
+	var violations []Violation
+	length := utf8.RuneCountInString(userpass)
+	if p.MinLength > 0 && length < p.MinLength {
+		violations = append(violations, Violation{Rule: RuleMinLength, Limit: p.MinLength})
+	}
+	tooLong := p.MaxLength > 0 && length > p.MaxLength
+	if tooLong {
+		violations = append(violations, Violation{Rule: RuleMaxLength, Limit: p.MaxLength})
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range userpass {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	classes := 0
+	for _, c := range []struct {
+		present, required bool
+		rule              string
+	}{{upper, p.RequireUpper, RuleUpper}, {lower, p.RequireLower, RuleLower}, {digit, p.RequireDigit, RuleDigit}, {symbol, p.RequireSymbol, RuleSymbol}} {
+		if c.present {
+			classes++
+		} else if c.required {
+			violations = append(violations, Violation{Rule: c.rule})
+		}
+	}
+	if classes < p.MinClasses {
+		violations = append(violations, Violation{Rule: RuleClasses, Limit: p.MinClasses})
+	}
+
+	folded := strings.ToLower(userpass)
+	for _, b := range p.Banned {
+		if b != "" && strings.Contains(folded, strings.ToLower(b)) {
+			violations = append(violations, Violation{Rule: RuleBanned, Match: b})
+		}
+	}
+	// Comparing identities costs the product of the lengths, so it is skipped for passphrases already too long
+	if p.RejectIdentity && !tooLong {
+		for _, id := range identities {
+			if similarIdentity(folded, id) {
+				violations = append(violations, Violation{Rule: RuleIdentity, Match: id})
+			}
+		}
+	}
+	if p.MinScore > 0 {
+		if s := EstimateStrength(userpass, identities...); s.Score < p.MinScore {
+			violations = append(violations, Violation{Rule: RuleStrength, Limit: p.MinScore, Strength: &s})
+		}
+	}
+	return violations
+}
+
+// similarIdentity reports whether the lower cased passphrase contains, is contained in or is within an edit distance
+// of a third of its length of the identity, or of the local part of an email identity. Identities shorter than
+// 3 runes are ignored, and only containment of the identity is checked for passphrases shorter than 3 runes, which
+// would otherwise be contained in or close to almost any identity. As in EstimateStrength only the first
+// strengthMaxRunes runes of each are compared by edit distance.
+func similarIdentity(folded, identity string) bool {
+	short := utf8.RuneCountInString(folded) < 3
+	runes := capRunes(folded)
+	candidates := []string{strings.ToLower(identity)}
+	if i := strings.LastIndexByte(identity, '@'); i > 0 {
+		candidates = append(candidates, strings.ToLower(identity[:i]))
+	}
+	for _, c := range candidates {
+		if utf8.RuneCountInString(c) < 3 {
+			continue
+		}
+		if strings.Contains(folded, c) {
+			return true
+		}
+		if short {
+			continue
+		}
+		if strings.Contains(c, folded) {
+			return true
+		}
+		if levenshtein(runes, capRunes(c))*3 <= len(runes) {
+			return true
+		}
+	}
+	return false
+}
+
+// capRunes returns the first strengthMaxRunes runes of s
+func capRunes(s string) []rune {
+	runes := []rune(s)
+	if len(runes) > strengthMaxRunes {
+		runes = runes[:strengthMaxRunes]
+	}
+	return runes
+}
+
+// levenshtein returns the edit distance between the runes ra and rb
+func levenshtein(ra, rb []rune) int {
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
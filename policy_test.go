@@ -0,0 +1,124 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	p := &Policy{
+		MinLength:      10,
+		MaxLength:      64,
+		RequireUpper:   true,
+		RequireDigit:   true,
+		MinClasses:     3,
+		Banned:         []string{"acme"},
+		RejectIdentity: true,
+	}
+	if err := p.Check("Correct4Horse-Battery", "jdoe", "jdoe@example.com"); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	violations := p.Violations("acmejdoe", "jdoe", "jdoe@example.com")
+	want := []string{RuleMinLength, RuleUpper, RuleDigit, RuleClasses, RuleBanned, RuleIdentity, RuleIdentity}
+	if len(violations) != len(want) {
+		t.Logf("Unexpected violations: %v", violations)
+		t.FailNow()
+	}
+	for i, v := range violations {
+		if v.Rule != want[i] {
+			t.Logf("Expected rule %s, got %s", want[i], v.Rule)
+			t.FailNow()
+		}
+	}
+
+	// Lengths count runes, not bytes
+	if v := (&Policy{MinLength: 10}).Violations("pässwördpä"); len(v) != 0 {
+		t.Logf("Unexpected violations: %v", v)
+		t.FailNow()
+	}
+	// Similar but not identical to the email local part
+	if v := p.Violations("J0hnSmith-99", "johnsmith@example.com"); len(v) != 1 || v[0].Rule != RuleIdentity {
+		t.Logf("Expected identity violation, got %v", v)
+		t.FailNow()
+	}
+
+	// Empty and very short passphrases are contained in most identities but are not similar to them
+	for _, userpass := range []string{"", "al"} {
+		if v := (&Policy{RejectIdentity: true}).Violations(userpass, "alice@example.com"); len(v) != 0 {
+			t.Logf("Unexpected violations for %q: %v", userpass, v)
+			t.FailNow()
+		}
+	}
+
+	// Long passphrases and identities are not compared in full
+	long := strings.Repeat("x", 1<<20)
+	if v := (&Policy{MaxLength: 64, RejectIdentity: true}).Violations(long, long); len(v) != 1 || v[0].Rule != RuleMaxLength {
+		t.Logf("Expected only a max length violation, got %v", v)
+		t.FailNow()
+	}
+	if v := (&Policy{RejectIdentity: true}).Violations("J0hnSmith-99", "johnsmith@"+long); len(v) != 1 || v[0].Rule != RuleIdentity {
+		t.Logf("Expected identity violation, got %v", v)
+		t.FailNow()
+	}
+
+	err := p.Check("short")
+	var perr *PolicyError
+	if !errors.As(err, &perr) || !errors.Is(err, ErrPolicyViolation) || !errors.Is(err, ErrPassphraseLength) {
+		t.Log("Expected *PolicyError matching ErrPolicyViolation and ErrPassphraseLength")
+		t.FailNow()
+	}
+	if errors.Is(p.Check("acmeacmeacme"), ErrPassphraseLength) {
+		t.Log("Expected no length violation")
+		t.FailNow()
+	}
+}
+
+func TestHasherPolicy(t *testing.T) {
+	h, err := NewHasher("masterpassphrase", 0, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	policy := &Policy{MinLength: 4, RequireDigit: true}
+	h.SetPolicy(policy)
+	// The policy replaces MinLength
+	output, err := h.Hash("pin1", testArgon2Params)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if _, err = h.Hash("password", testArgon2Params); !errors.Is(err, ErrPolicyViolation) {
+		t.Log("Expected ErrPolicyViolation")
+		t.FailNow()
+	}
+
+	k := &Keyring{}
+	if err = k.AddHasher(h); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	k.SetPolicy(&Policy{MinLength: 12})
+	if _, err = k.Hash("password1234", testArgon2Params); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if _, err = k.Hash("pin1", testArgon2Params); !errors.Is(err, ErrPassphraseLength) {
+		t.Log("Expected Keyring policy length violation")
+		t.FailNow()
+	}
+	// A stricter policy does not block upgrading an existing hash
+	upgraded, err := VerifyAndUpgrade("pin1", k, output, RehashPolicy{UserParams: Argon2Params{Time: 2, Memory: 8 * 1024, Threads: 1, KeyLen: 32}})
+	if err != nil || upgraded == "" {
+		t.Log("Expected upgrade", err)
+		t.FailNow()
+	}
+
+	h.SetPolicy(nil)
+	if _, err = h.Hash("pin1", testArgon2Params); err != ErrPassphraseLength {
+		t.Log("Expected MinLength check")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,163 @@
+package password
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// KeyProvider supplies master passphrases by version so that they need not be held in process configuration.
+// Implementations must be safe for concurrent use.
+type KeyProvider interface {
+	// GetKey returns the master passphrase for version, or ErrUnknownMasterVersion if there is none
+	GetKey(version int) (string, error)
+	// CurrentVersion returns the master passphrase version used for new hashes
+	CurrentVersion() (int, error)
+}
+
+// EnvProvider reads master passphrases from environment variables named by a prefix followed by the version,
+// ex. SBPASSWORD_MASTER_1, and the current version from the prefix followed by CURRENT, ex. SBPASSWORD_MASTER_CURRENT.
+type EnvProvider struct {
+	prefix string
+}
+
+// NewEnvProvider takes the environment variable name prefix as string and returns an EnvProvider - ex. password.NewEnvProvider("SBPASSWORD_MASTER_")
+func NewEnvProvider(prefix string) *EnvProvider {
+	return &EnvProvider{prefix: prefix}
+}
+
+// GetKey returns the master passphrase for version from the environment
+func (p *EnvProvider) GetKey(version int) (string, error) {
+	masterpass, ok := os.LookupEnv(p.prefix + strconv.Itoa(version))
+	if !ok || masterpass == "" {
+		return "", ErrUnknownMasterVersion
+	}
+	return masterpass, nil
+}
+
+// CurrentVersion returns the current master passphrase version from the environment
+func (p *EnvProvider) CurrentVersion() (int, error) {
+	s, ok := os.LookupEnv(p.prefix + "CURRENT")
+	if !ok {
+		return 0, ErrUnknownMasterVersion
+	}
+	return strconv.Atoi(s)
+}
+
+// FileProvider reads master passphrases from one file per version. Files must not be accessible by group or others,
+// ex. mode 0600, except on Windows where permission bits are not checked. A trailing newline is removed.
+type FileProvider struct {
+	current int
+	paths   map[int]string
+}
+
+// NewFileProvider takes the current version as int and file paths by version and returns a FileProvider
+// - ex. password.NewFileProvider(1, map[int]string{0: "/etc/app/master0", 1: "/etc/app/master1"})
+func NewFileProvider(current int, paths map[int]string) *FileProvider {
+	copied := make(map[int]string, len(paths))
+	for v, path := range paths {
+		copied[v] = path
+	}
+	return &FileProvider{current: current, paths: copied}
+}
+
+// GetKey returns the master passphrase for version read from its file
+func (p *FileProvider) GetKey(version int) (string, error) {
+	path, ok := p.paths[version]
+	if !ok {
+		return "", ErrUnknownMasterVersion
+	}
+	b, err := readPrivateFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// readPrivateFile returns the contents of the file at path, or ErrKeyFilePermissions if it is accessible by group or others
+func readPrivateFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("%w: %s has mode %v", ErrKeyFilePermissions, path, info.Mode().Perm())
+	}
+	return io.ReadAll(f)
+}
+
+// CurrentVersion returns the current version given to NewFileProvider
+func (p *FileProvider) CurrentVersion() (int, error) {
+	return p.current, nil
+}
+
+// EnvelopeProvider holds master passphrases encrypted under a key encryption key held elsewhere, ex. a cloud KMS,
+// and decrypts them with an unwrap function on each GetKey so that only wrapped passphrases are stored in configuration.
+type EnvelopeProvider struct {
+	current int
+	wrapped map[int][]byte
+	unwrap  func(wrapped []byte) ([]byte, error)
+}
+
+// NewEnvelopeProvider takes the current version as int, wrapped master passphrases by version and the unwrap function
+// decrypting them, ex. a call to a KMS Decrypt API, and returns an EnvelopeProvider
+func NewEnvelopeProvider(current int, wrapped map[int][]byte, unwrap func(wrapped []byte) ([]byte, error)) *EnvelopeProvider {
+	copied := make(map[int][]byte, len(wrapped))
+	for v, w := range wrapped {
+		copied[v] = append([]byte(nil), w...)
+	}
+	return &EnvelopeProvider{current: current, wrapped: copied, unwrap: unwrap}
+}
+
+// GetKey returns the master passphrase for version decrypted by the unwrap function
+func (p *EnvelopeProvider) GetKey(version int) (string, error) {
+	w, ok := p.wrapped[version]
+	if !ok {
+		return "", ErrUnknownMasterVersion
+	}
+	masterpass, err := p.unwrap(w)
+	if err != nil {
+		return "", err
+	}
+	return string(masterpass), nil
+}
+
+// CurrentVersion returns the current version given to NewEnvelopeProvider
+func (p *EnvelopeProvider) CurrentVersion() (int, error) {
+	return p.current, nil
+}
+
+// NewKeyringFromProvider takes provider as KeyProvider, masterparams as ScryptParams and any older versions still
+// needed to verify stored hashes, and returns a Keyring holding the current version of provider and those versions
+// - ex. password.NewKeyringFromProvider(password.NewEnvProvider("SBPASSWORD_MASTER_"), DefaultParams, 0)
+func NewKeyringFromProvider(provider KeyProvider, masterparams ScryptParams, versions ...int) (*Keyring, error) {
+	current, err := provider.CurrentVersion()
+	if err != nil {
+		return nil, err
+	}
+	k := &Keyring{hashers: make(map[int]*Hasher)}
+	for _, version := range append([]int{current}, versions...) {
+		if _, ok := k.hashers[version]; ok {
+			continue
+		}
+		masterpass, err := provider.GetKey(version)
+		if err != nil {
+			return nil, err
+		}
+		if err = k.Add(version, masterpass, masterparams); err != nil {
+			return nil, err
+		}
+	}
+	if err = k.SetCurrent(current); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
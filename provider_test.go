@@ -0,0 +1,121 @@
+package password
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+func TestEnvProvider(t *testing.T) {
+	os.Setenv("SBPASSWORD_TEST_MASTER_1", "masterpassphrase1")
+	os.Setenv("SBPASSWORD_TEST_MASTER_CURRENT", "1")
+	defer os.Unsetenv("SBPASSWORD_TEST_MASTER_1")
+	defer os.Unsetenv("SBPASSWORD_TEST_MASTER_CURRENT")
+
+	p := NewEnvProvider("SBPASSWORD_TEST_MASTER_")
+	if v, err := p.CurrentVersion(); err != nil || v != 1 {
+		t.Log("Expected current version 1", err)
+		t.FailNow()
+	}
+	if masterpass, err := p.GetKey(1); err != nil || masterpass != "masterpassphrase1" {
+		t.Log("Unexpected master passphrase", err)
+		t.FailNow()
+	}
+	if _, err := p.GetKey(2); err != ErrUnknownMasterVersion {
+		t.Log("Expected ErrUnknownMasterVersion")
+		t.FailNow()
+	}
+}
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "master0")
+	if err := os.WriteFile(path, []byte("masterpassphrase\n"), 0600); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	p := NewFileProvider(0, map[int]string{0: path})
+	masterpass, err := p.GetKey(0)
+	if err != nil || masterpass != "masterpassphrase" {
+		t.Log("Unexpected master passphrase", err)
+		t.FailNow()
+	}
+	if _, err = p.GetKey(1); err != ErrUnknownMasterVersion {
+		t.Log("Expected ErrUnknownMasterVersion")
+		t.FailNow()
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	if err = os.Chmod(path, 0644); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if _, err = p.GetKey(0); !errors.Is(err, ErrKeyFilePermissions) {
+		t.Log("Expected ErrKeyFilePermissions, got", err)
+		t.FailNow()
+	}
+}
+
+func TestEnvelopeProvider(t *testing.T) {
+	// Local stand-in for a KMS holding the key encryption key
+	var kek [32]byte
+	copy(kek[:], "0123456789abcdef0123456789abcdef")
+	var nonce [24]byte
+	wrap := func(masterpass string) []byte {
+		return secretbox.Seal(nonce[:], []byte(masterpass), &nonce, &kek)
+	}
+	unwrap := func(wrapped []byte) ([]byte, error) {
+		var n [24]byte
+		copy(n[:], wrapped)
+		masterpass, ok := secretbox.Open(nil, wrapped[24:], &n, &kek)
+		if !ok {
+			return nil, ErrSecretBoxDecryptFail
+		}
+		return masterpass, nil
+	}
+	wrapped := map[int][]byte{0: wrap("masterpassphrase"), 1: wrap("masterpassphrase1"), 2: []byte("corrupt wrapped master passphrase")}
+	p := NewEnvelopeProvider(1, wrapped, unwrap)
+
+	k, err := NewKeyringFromProvider(p, DefaultParams, 0)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if k.Current() != 1 || len(k.Versions()) != 2 {
+		t.Log("Unexpected keyring state")
+		t.FailNow()
+	}
+	output, err := k.Hash("password1234", testArgon2Params)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err = Verify("password1234", "masterpassphrase1", output); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	old, err := HashV2("password1234", "masterpassphrase", 0, testArgon2Params, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err = k.Verify("password1234", old); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	if _, err = NewKeyringFromProvider(p, DefaultParams, 2); err != ErrSecretBoxDecryptFail {
+		t.Log("Expected unwrap error")
+		t.FailNow()
+	}
+	if _, err = NewKeyringFromProvider(p, DefaultParams, 3); err != ErrUnknownMasterVersion {
+		t.Log("Expected ErrUnknownMasterVersion")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,101 @@
+package password
+
+import "context"
+
+// RehashPolicy describes the hash format version, master passphrase version and parameters an application wants
+// stored hashes to use. Zero value fields are not compared.
+type RehashPolicy struct {
+	// Version is the minimum hash format version, ex. 2 for secBoxv2
+	Version int
+	// MasterVersion is the minimum master passphrase version
+	MasterVersion int
+	// Cipher is the wanted master layer cipher, ex. CipherAES256GCM
+	Cipher string
+	// UserParams are the wanted user passphrase KDF and parameters, ScryptParams or Argon2Params
+	UserParams UserParams
+	// MasterParams are the wanted master passphrase Scrypt parameters
+	MasterParams ScryptParams
+}
+
+// NeedsRehash takes ciphertext as string and policy as RehashPolicy and returns true if the stored hash uses an older
+// format or master passphrase version, or cipher, user or master parameters differing from policy, and error.
+func NeedsRehash(ciphertext string, policy RehashPolicy) (bool, error) {
+	parsed, err := ParseHash(ciphertext)
+	if err != nil {
+		return false, err
+	}
+	return needsRehash(parsed, policy), nil
+}
+
+func needsRehash(parsed *ParsedHash, policy RehashPolicy) bool {
+	if parsed.Version < policy.Version {
+		return true
+	}
+	if parsed.MasterVersion < policy.MasterVersion {
+		return true
+	}
+	if policy.Cipher != "" && parsed.Cipher != policy.Cipher {
+		return true
+	}
+	if policy.UserParams != nil && parsed.UserParams != policy.UserParams {
+		return true
+	}
+	if policy.MasterParams != (ScryptParams{}) && parsed.MasterParams != policy.MasterParams {
+		return true
+	}
+	return false
+}
+
+// VerifyAndUpgrade takes passphrase as string, masterKeys as Keyring, ciphertext as string and policy as RehashPolicy.
+// It returns error if verification fails, otherwise if the stored hash is stale per NeedsRehash with the same policy
+// it returns a freshly computed hash from the Keyring's current Hasher, which should replace the stored hash, and an
+// empty string if not. If policy.UserParams is nil the stored user parameters are kept. ErrRehashPolicy is returned
+// if the current Hasher cannot write a hash meeting policy, as the hash would otherwise be stale on every call: its
+// version must be at least policy.MasterVersion, and its cipher, master parameters and format version, 2 for
+// secretbox and 3 otherwise, must match policy where set.
+func VerifyAndUpgrade(userpass string, masterKeys *Keyring, ciphertext string, policy RehashPolicy) (newHash string, err error) {
+	parsed, err := ParseHash(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	current, err := masterKeys.Hasher(masterKeys.Current())
+	if err != nil {
+		return "", err
+	}
+	if !current.meets(policy) {
+		return "", ErrRehashPolicy
+	}
+	old, err := masterKeys.Hasher(parsed.MasterVersion)
+	if err != nil {
+		return "", err
+	}
+	err = old.verify(context.Background(), userpass, parsed)
+	if err != nil {
+		return "", err
+	}
+	if !needsRehash(parsed, policy) {
+		return "", nil
+	}
+	userparams := policy.UserParams
+	if userparams == nil {
+		userparams = parsed.UserParams
+	}
+	// The passphrase was accepted when first hashed, a stricter Policy set since must not block the upgrade
+	return current.hash(context.Background(), userpass, userparams)
+}
+
+// meets reports whether hashes written by h satisfy the master and format fields of policy
+func (h *Hasher) meets(policy RehashPolicy) bool {
+	cipher := h.Cipher()
+	switch {
+	case formatVersion(cipher) < policy.Version:
+		return false
+	case h.version < policy.MasterVersion:
+		return false
+	case policy.Cipher != "" && policy.Cipher != cipher:
+		return false
+	case policy.MasterParams != (ScryptParams{}) && policy.MasterParams != h.masterParams:
+		return false
+	}
+	return true
+}
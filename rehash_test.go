@@ -0,0 +1,169 @@
+package password
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNeedsRehash(t *testing.T) {
+	v1, err := Hash("password1234", "masterpassphrase", 0, DefaultParams, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	v2, err := HashV2("password1234", "masterpassphrase", 1, testArgon2Params, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	tests := []struct {
+		ciphertext string
+		policy     RehashPolicy
+		want       bool
+	}{
+		{v1, RehashPolicy{}, false},
+		{v1, RehashPolicy{Version: 1, UserParams: DefaultParams, MasterParams: DefaultParams}, false},
+		{v1, RehashPolicy{Version: 2}, true},
+		{v1, RehashPolicy{MasterVersion: 1}, true},
+		{v1, RehashPolicy{UserParams: ScryptParams{N: 32768, R: 8, P: 1}}, true},
+		{v1, RehashPolicy{UserParams: testArgon2Params}, true},
+		{v1, RehashPolicy{MasterParams: ScryptParams{N: 32768, R: 8, P: 1}}, true},
+		{v2, RehashPolicy{Version: 2, MasterVersion: 1, UserParams: testArgon2Params, MasterParams: DefaultParams}, false},
+		{v2, RehashPolicy{UserParams: DefaultArgon2Params}, true},
+	}
+	for i, test := range tests {
+		got, err := NeedsRehash(test.ciphertext, test.policy)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		if got != test.want {
+			t.Logf("Test %v: expected NeedsRehash %v", i, test.want)
+			t.FailNow()
+		}
+	}
+
+	if _, err := NeedsRehash("secBoxv1$0", RehashPolicy{}); !errors.Is(err, ErrCiphertextFormat) {
+		t.Log("Expected Ciphertext format failure")
+		t.FailNow()
+	}
+}
+
+func TestVerifyAndUpgrade(t *testing.T) {
+	k, err := NewKeyring(1, DefaultParams, map[int]string{0: "masterpassphrase", 1: "masterpassphrase1"})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	policy := RehashPolicy{Version: 2, UserParams: testArgon2Params}
+
+	// Stale v1 hash under old master passphrase is upgraded
+	v1, err := Hash("password1234", "masterpassphrase", 0, DefaultParams, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	upgraded, err := VerifyAndUpgrade("password1234", k, v1, policy)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if upgraded == "" {
+		t.Log("Expected upgraded hash")
+		t.FailNow()
+	}
+	if v, _ := GetMasterVersion(upgraded); v != 1 {
+		t.Log("Expected current master version")
+		t.FailNow()
+	}
+	if user, _, err := GetKDFParams(upgraded); err != nil || user != testArgon2Params {
+		t.Log("Expected policy user params")
+		t.FailNow()
+	}
+	if err := Verify("password1234", "masterpassphrase1", upgraded); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	// Current hash is not upgraded
+	again, err := VerifyAndUpgrade("password1234", k, upgraded, policy)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if again != "" {
+		t.Log("Expected no upgrade for current hash")
+		t.FailNow()
+	}
+
+	// Policies the current secretbox Hasher cannot write hashes for are rejected rather than left stale
+	for i, conflict := range []RehashPolicy{
+		{Version: 3},
+		{MasterVersion: 2},
+		{Cipher: CipherAES256GCM},
+		{MasterParams: ScryptParams{N: 8192, R: 8, P: 1}},
+	} {
+		if _, err = VerifyAndUpgrade("password1234", k, upgraded, conflict); err != ErrRehashPolicy {
+			t.Logf("Policy %v: expected ErrRehashPolicy, got %v", i, err)
+			t.FailNow()
+		}
+	}
+
+	// Failed verification never upgrades
+	again, err = VerifyAndUpgrade("passw0rd1234", k, v1, policy)
+	if err != ErrPassphraseHashMismatch || again != "" {
+		t.Log("Expected passphrase mismatch")
+		t.FailNow()
+	}
+}
+
+func TestVerifyAndUpgradeAgreesWithNeedsRehash(t *testing.T) {
+	k, err := NewKeyring(1, DefaultParams, map[int]string{0: "masterpassphrase", 1: "masterpassphrase1"})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	v1, err := Hash("password1234", "masterpassphrase", 0, DefaultParams, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	current, err := k.Hash("password1234", testArgon2Params)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	policies := []RehashPolicy{
+		{},
+		{Version: 2},
+		{MasterVersion: 1},
+		{Cipher: CipherSecretbox, MasterParams: DefaultParams},
+		{UserParams: testArgon2Params},
+		{UserParams: DefaultParams},
+	}
+	for _, ciphertext := range []string{v1, current} {
+		for i, policy := range policies {
+			stale, err := NeedsRehash(ciphertext, policy)
+			if err != nil {
+				t.Log(err)
+				t.FailNow()
+			}
+			upgraded, err := VerifyAndUpgrade("password1234", k, ciphertext, policy)
+			if err != nil {
+				t.Log(err)
+				t.FailNow()
+			}
+			if stale != (upgraded != "") {
+				t.Logf("Policy %v: NeedsRehash %v but VerifyAndUpgrade returned %q", i, stale, upgraded)
+				t.FailNow()
+			}
+			if upgraded == "" {
+				continue
+			}
+			if stale, _ = NeedsRehash(upgraded, policy); stale {
+				t.Logf("Policy %v: upgraded hash is still stale", i)
+				t.FailNow()
+			}
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package password
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// RotateOptions configures RotateStream
+type RotateOptions struct {
+	// Workers is the number of records rotated concurrently, defaults to runtime.NumCPU()
+	Workers int
+	// CSV reads and writes records as CSV rather than one hash per line, other columns are passed through unchanged
+	CSV bool
+	// HashColumn is the zero based CSV column holding the hash
+	HashColumn int
+	// IDColumn is the zero based CSV column holding the record ID reported in RecordError, or -1 for none
+	IDColumn int
+	// Header passes the first CSV record through unchanged
+	Header bool
+	// Skip is the number of records to discard before rotating, set it to the last value passed to Progress to resume
+	// an interrupted rotation while appending to the previous output
+	Skip int64
+	// OnError is called for each record that could not be rotated, the record is written unchanged
+	OnError func(err *RecordError)
+	// Progress is called in input order every ProgressInterval records, and once all records are written, with the
+	// number of records done including Skip. Output is flushed to w before each call, so the count can be used as Skip
+	// for an output w that has not lost written data, ex. a file that was not truncated by a crash.
+	Progress func(done int64)
+	// ProgressInterval is the number of records between Progress calls, defaults to 10000
+	ProgressInterval int64
+}
+
+// RotateStats reports the outcome of RotateStream
+type RotateStats struct {
+	// Rotated records were re-encrypted under the current master passphrase version
+	Rotated int64
+	// Unchanged records were already at the current master passphrase version, or the CSV header
+	Unchanged int64
+	// Failed records were reported to OnError
+	Failed int64
+}
+
+// RecordError describes a record RotateStream could not rotate
+type RecordError struct {
+	// Record is the zero based record number in the input
+	Record int64
+	// ID is the value of the IDColumn for CSV input
+	ID  string
+	Err error
+}
+
+func (e *RecordError) Error() string {
+	if e.ID != "" {
+		return fmt.Sprintf("record %v (id %s): %v", e.Record, e.ID, e.Err)
+	}
+	return fmt.Sprintf("record %v: %v", e.Record, e.Err)
+}
+
+// rotateItem is a record in flight, done is closed once fields holds the output record
+type rotateItem struct {
+	n      int64
+	fields []string
+	header bool
+	result rotateResult
+	done   chan struct{}
+}
+
+// defaultProgressInterval is the default RotateOptions.ProgressInterval
+const defaultProgressInterval = 10000
+
+type rotateResult int
+
+const (
+	resultRotated rotateResult = iota
+	resultUnchanged
+	resultFailed
+)
+
+// RotateStream reads hashes from r, re-encrypts each under the current master passphrase version of masterKeys and
+// writes them to w in input order. Hashes are read one per line, or from a CSV column if opts.CSV is set. Records are
+// rotated by a bounded pool of workers, each master passphrase Scrypt hash is derived once by the Keyring's Hashers.
+// Records already at the current version are written unchanged, records that fail are written unchanged and
+// reported to opts.OnError. The returned error is only non-nil for read or write failures.
+func RotateStream(r io.Reader, w io.Writer, masterKeys *Keyring, opts RotateOptions) (stats RotateStats, err error) {
+	if opts.Workers < 1 {
+		opts.Workers = runtime.NumCPU()
+	}
+	if opts.ProgressInterval < 1 {
+		opts.ProgressInterval = defaultProgressInterval
+	}
+	read, write, flush := recordIO(r, w, opts)
+	current := masterKeys.Current()
+
+	jobs := make(chan *rotateItem)
+	// ordered bounds the records in flight and lets output be written in input order
+	ordered := make(chan *rotateItem, 2*opts.Workers)
+	quit := make(chan struct{})
+	var readErr error
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range jobs {
+				rotateRecord(masterKeys, current, item, opts)
+				close(item.done)
+			}
+		}()
+	}
+	go func() {
+		defer close(ordered)
+		defer close(jobs)
+		for n := int64(0); ; n++ {
+			fields, err := read()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				readErr = err
+				return
+			}
+			if n < opts.Skip {
+				continue
+			}
+			item := &rotateItem{n: n, fields: fields, done: make(chan struct{})}
+			if opts.CSV && opts.Header && n == 0 {
+				item.header = true
+			}
+			select {
+			case ordered <- item:
+			case <-quit:
+				return
+			}
+			select {
+			case jobs <- item:
+			case <-quit:
+				close(item.done)
+				return
+			}
+		}
+	}()
+	defer func() {
+		close(quit)
+		// Drain so the reader and workers exit
+		for item := range ordered {
+			<-item.done
+		}
+		wg.Wait()
+	}()
+
+	done := opts.Skip
+	for item := range ordered {
+		<-item.done
+		switch item.result {
+		case resultRotated:
+			stats.Rotated++
+		case resultUnchanged:
+			stats.Unchanged++
+		case resultFailed:
+			stats.Failed++
+		}
+		if err = write(item.fields); err != nil {
+			return stats, err
+		}
+		done++
+		if opts.Progress != nil && (done-opts.Skip)%opts.ProgressInterval == 0 {
+			// Flushing every record would make large rotations bound by write calls, so output is only flushed
+			// for a checkpoint, which must never count records still buffered
+			if err = flush(); err != nil {
+				return stats, err
+			}
+			opts.Progress(done)
+		}
+	}
+	if readErr != nil {
+		return stats, readErr
+	}
+	if err = flush(); err != nil {
+		return stats, err
+	}
+	if opts.Progress != nil && (done-opts.Skip)%opts.ProgressInterval != 0 {
+		opts.Progress(done)
+	}
+	return stats, nil
+}
+
+func rotateRecord(masterKeys *Keyring, current int, item *rotateItem, opts RotateOptions) {
+	if item.header {
+		item.result = resultUnchanged
+		return
+	}
+	col := 0
+	if opts.CSV {
+		col = opts.HashColumn
+	}
+	fail := func(err error) {
+		item.result = resultFailed
+		if opts.OnError != nil {
+			rerr := &RecordError{Record: item.n, Err: err}
+			if opts.CSV && opts.IDColumn >= 0 && opts.IDColumn < len(item.fields) {
+				rerr.ID = item.fields[opts.IDColumn]
+			}
+			opts.OnError(rerr)
+		}
+	}
+	if col < 0 || col >= len(item.fields) {
+		fail(ErrCiphertextFormat)
+		return
+	}
+	parsed, err := ParseHash(item.fields[col])
+	if err != nil {
+		fail(err)
+		return
+	}
+	if parsed.MasterVersion == current {
+		item.result = resultUnchanged
+		return
+	}
+	rotated, err := masterKeys.rotate(parsed)
+	if err != nil {
+		fail(err)
+		return
+	}
+	item.fields[col] = rotated
+	item.result = resultRotated
+}
+
+// recordIO returns functions reading and writing records as lines or CSV
+func recordIO(r io.Reader, w io.Writer, opts RotateOptions) (read func() ([]string, error), write func([]string) error, flush func() error) {
+	if opts.CSV {
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cw := csv.NewWriter(w)
+		return cr.Read, cw.Write, func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	}
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+	read = func() ([]string, error) {
+		line, err := br.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []string{strings.TrimRight(line, "\r\n")}, nil
+	}
+	write = func(fields []string) error {
+		_, err := bw.WriteString(fields[0] + "\n")
+		return err
+	}
+	return read, write, bw.Flush
+}
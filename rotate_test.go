@@ -0,0 +1,160 @@
+package password
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func rotateTestKeyring(t *testing.T) (*Keyring, []string) {
+	k, err := NewKeyring(1, DefaultParams, map[int]string{0: "masterpassphrase", 1: "masterpassphrase1"})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	old, _ := k.Hasher(0)
+	var hashes []string
+	for i := 0; i < 6; i++ {
+		h, err := old.Hash("password1234", testArgon2Params)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		hashes = append(hashes, h)
+	}
+	return k, hashes
+}
+
+func TestRotateStream(t *testing.T) {
+	k, hashes := rotateTestKeyring(t)
+	current, err := k.Hash("password1234", testArgon2Params)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	input := append(append([]string{}, hashes...), current, "not a hash")
+
+	var out bytes.Buffer
+	var failed []*RecordError
+	var progress []int64
+	stats, err := RotateStream(strings.NewReader(strings.Join(input, "\n")+"\n"), &out, k, RotateOptions{
+		Workers:          3,
+		OnError:          func(err *RecordError) { failed = append(failed, err) },
+		Progress:         func(done int64) { progress = append(progress, done) },
+		ProgressInterval: 3,
+	})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if stats.Rotated != 6 || stats.Unchanged != 1 || stats.Failed != 1 {
+		t.Logf("Unexpected stats %+v", stats)
+		t.FailNow()
+	}
+	if len(failed) != 1 || failed[0].Record != 7 || !errors.Is(failed[0].Err, ErrCiphertextFormat) {
+		t.Log("Expected failure for record 7")
+		t.FailNow()
+	}
+	if len(progress) != 3 || progress[0] != 3 || progress[1] != 6 || progress[2] != int64(len(input)) {
+		t.Logf("Expected progress every 3 records and at the end, got %v", progress)
+		t.FailNow()
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != len(input) {
+		t.Log("Expected one output line per input line")
+		t.FailNow()
+	}
+	for i, line := range lines[:6] {
+		if v, _ := GetMasterVersion(line); v != 1 {
+			t.Logf("Expected line %v at master version 1", i)
+			t.FailNow()
+		}
+		if err := Verify("password1234", "masterpassphrase1", line); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+	}
+	if lines[6] != current || lines[7] != "not a hash" {
+		t.Log("Expected unchanged and failed records to be passed through")
+		t.FailNow()
+	}
+}
+
+func TestRotateStreamCSVResume(t *testing.T) {
+	k, hashes := rotateTestKeyring(t)
+	var in bytes.Buffer
+	cw := csv.NewWriter(&in)
+	cw.Write([]string{"id", "hash", "email"})
+	for i, h := range hashes {
+		cw.Write([]string{string(rune('a' + i)), h, "user@example.com"})
+	}
+	cw.Flush()
+
+	// Resume after the header and the first two rows
+	var out bytes.Buffer
+	stats, err := RotateStream(bytes.NewReader(in.Bytes()), &out, k, RotateOptions{
+		CSV:        true,
+		HashColumn: 1,
+		IDColumn:   0,
+		Header:     true,
+		Skip:       3,
+	})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if stats.Rotated != 4 {
+		t.Logf("Unexpected stats %+v", stats)
+		t.FailNow()
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if len(records) != 4 || records[0][0] != "c" || records[0][2] != "user@example.com" {
+		t.Log("Expected remaining records with columns passed through")
+		t.FailNow()
+	}
+	for _, rec := range records {
+		if err := Verify("password1234", "masterpassphrase1", rec[1]); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+	}
+
+	// Record errors carry the ID column
+	var failed *RecordError
+	_, err = RotateStream(strings.NewReader("x1,bad\n"), &out, k, RotateOptions{
+		CSV:        true,
+		HashColumn: 1,
+		OnError:    func(err *RecordError) { failed = err },
+	})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if failed == nil || failed.ID != "x1" {
+		t.Log("Expected record error with ID")
+		t.FailNow()
+	}
+}
+
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) { return 0, errors.New("write failed") }
+
+func TestRotateStreamWriteError(t *testing.T) {
+	k, hashes := rotateTestKeyring(t)
+	_, err := RotateStream(strings.NewReader(strings.Join(hashes, "\n")), failWriter{}, k, RotateOptions{
+		Workers:  2,
+		Progress: func(int64) {},
+	})
+	if err == nil || err.Error() != "write failed" {
+		t.Log("Expected write failure")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,162 @@
+package password
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// sharePrefix identifies and versions master passphrase shares
+const sharePrefix = "sbshare1"
+
+// SplitMaster takes masterpassphrase as string, shares as the number of shares n and threshold as the number k
+// needed to reconstruct it, and returns n printable shares using Shamir's secret sharing over GF(256) and error.
+// Fewer than k shares reveal nothing about the master passphrase. Each share is of the form
+// sbshare1-<split id>-<k>-<x>-<hex data>-<checksum> so that mistyped shares and shares from different splits are
+// detected - ex. password.SplitMaster("masterpassphrase", 5, 3)
+func SplitMaster(masterpass string, shares, threshold int) ([]string, error) {
+	if len(masterpass) < MinLength {
+		return nil, ErrPassphraseLength
+	}
+	if threshold < 2 || shares < threshold || shares > 255 {
+		return nil, ErrShareThreshold
+	}
+	id, err := randBytes(nil, 4)
+	if err != nil {
+		return nil, err
+	}
+	// One random polynomial of degree threshold-1 per byte with the secret byte as constant term
+	coeffs, err := randBytes(nil, len(masterpass)*(threshold-1))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, shares)
+	y := make([]byte, len(masterpass))
+	for i := range out {
+		x := byte(i + 1)
+		for b := range y {
+			poly := coeffs[b*(threshold-1) : (b+1)*(threshold-1)]
+			// Horner's method from the highest degree coefficient
+			var acc byte
+			for d := len(poly) - 1; d >= 0; d-- {
+				acc = gfMul(acc, x) ^ poly[d]
+			}
+			y[b] = gfMul(acc, x) ^ masterpass[b]
+		}
+		out[i] = encodeShare(id, threshold, x, y)
+	}
+	return out, nil
+}
+
+// CombineMaster takes shares produced by SplitMaster and returns the master passphrase and error. At least the
+// threshold number of shares from the same split are required, extra shares are ignored.
+func CombineMaster(shares []string) (string, error) {
+	var id []byte
+	var threshold int
+	xs := make([]byte, 0, len(shares))
+	ys := make([][]byte, 0, len(shares))
+	for _, s := range shares {
+		sid, k, x, y, err := decodeShare(s)
+		if err != nil {
+			return "", err
+		}
+		if id == nil {
+			id, threshold = sid, k
+		} else if subtle.ConstantTimeCompare(id, sid) != 1 || k != threshold || len(y) != len(ys[0]) {
+			return "", ErrShareMismatch
+		}
+		for _, seen := range xs {
+			if seen == x {
+				return "", ErrShareMismatch
+			}
+		}
+		xs = append(xs, x)
+		ys = append(ys, y)
+	}
+	if len(xs) == 0 || len(xs) < threshold {
+		return "", ErrShareThreshold
+	}
+	xs, ys = xs[:threshold], ys[:threshold]
+
+	// Lagrange interpolation at x = 0, in GF(256) subtraction is addition
+	secret := make([]byte, len(ys[0]))
+	for i := range xs {
+		basis := byte(1)
+		for j := range xs {
+			if i != j {
+				basis = gfMul(basis, gfMul(xs[j], gfInv(xs[j]^xs[i])))
+			}
+		}
+		for b := range secret {
+			secret[b] ^= gfMul(ys[i][b], basis)
+		}
+	}
+	return string(secret), nil
+}
+
+func encodeShare(id []byte, threshold int, x byte, y []byte) string {
+	body := fmt.Sprintf("%s-%x-%d-%d-%x", sharePrefix, id, threshold, x, y)
+	return body + "-" + shareChecksum(body)
+}
+
+func decodeShare(s string) (id []byte, threshold int, x byte, y []byte, err error) {
+	s = strings.TrimSpace(s)
+	i := strings.LastIndexByte(s, '-')
+	if i < 0 {
+		return nil, 0, 0, nil, ErrShareFormat
+	}
+	if subtle.ConstantTimeCompare([]byte(shareChecksum(s[:i])), []byte(strings.ToLower(s[i+1:]))) != 1 {
+		return nil, 0, 0, nil, ErrShareChecksum
+	}
+	parts := strings.Split(s[:i], "-")
+	if len(parts) != 5 || parts[0] != sharePrefix {
+		return nil, 0, 0, nil, ErrShareFormat
+	}
+	id, err = hex.DecodeString(parts[1])
+	if err != nil || len(id) != 4 {
+		return nil, 0, 0, nil, ErrShareFormat
+	}
+	threshold, err = strconv.Atoi(parts[2])
+	if err != nil || threshold < 2 || threshold > 255 {
+		return nil, 0, 0, nil, ErrShareFormat
+	}
+	xi, err := strconv.Atoi(parts[3])
+	if err != nil || xi < 1 || xi > 255 {
+		return nil, 0, 0, nil, ErrShareFormat
+	}
+	y, err = hex.DecodeString(parts[4])
+	if err != nil || len(y) == 0 {
+		return nil, 0, 0, nil, ErrShareFormat
+	}
+	return id, threshold, byte(xi), y, nil
+}
+
+// shareChecksum returns the first 4 bytes of the Blake2b-256 hash of the share body as hex
+func shareChecksum(body string) string {
+	sum := blake2b.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:4])
+}
+
+// gfMul multiplies in GF(2^8) with the AES polynomial x^8 + x^4 + x^3 + x + 1 without data dependent branches
+func gfMul(a, b byte) byte {
+	var p byte
+	for i := 0; i < 8; i++ {
+		p ^= -(b & 1) & a
+		a = (a << 1) ^ (-(a >> 7) & 0x1b)
+		b >>= 1
+	}
+	return p
+}
+
+// gfInv returns the multiplicative inverse of a non-zero a as a^254
+func gfInv(a byte) byte {
+	r := a
+	for i := 0; i < 6; i++ {
+		r = gfMul(gfMul(r, r), a)
+	}
+	return gfMul(r, r)
+}
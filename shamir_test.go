@@ -0,0 +1,86 @@
+package password
+
+import "testing"
+
+func TestGF256(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		if gfMul(byte(a), gfInv(byte(a))) != 1 {
+			t.Logf("Inverse of %v incorrect", a)
+			t.FailNow()
+		}
+	}
+	// 0x53 * 0xCA = 0x01 in the AES field
+	if gfMul(0x53, 0xca) != 1 {
+		t.Log("Unexpected GF(256) product")
+		t.FailNow()
+	}
+}
+
+func TestSplitMaster(t *testing.T) {
+	masterpass := "masterpassphrase"
+	shares, err := SplitMaster(masterpass, 5, 3)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if len(shares) != 5 {
+		t.Log("Expected 5 shares")
+		t.FailNow()
+	}
+	// Every combination of 3 shares reconstructs the master passphrase
+	for i := 0; i < 5; i++ {
+		for j := i + 1; j < 5; j++ {
+			for k := j + 1; k < 5; k++ {
+				combined, err := CombineMaster([]string{shares[k], shares[i], shares[j]})
+				if err != nil || combined != masterpass {
+					t.Logf("Shares %v %v %v did not reconstruct master passphrase: %v", i, j, k, err)
+					t.FailNow()
+				}
+			}
+		}
+	}
+	if combined, err := CombineMaster(shares); err != nil || combined != masterpass {
+		t.Log("Expected extra shares to be ignored", err)
+		t.FailNow()
+	}
+	if _, err = CombineMaster(shares[:2]); err != ErrShareThreshold {
+		t.Log("Expected ErrShareThreshold")
+		t.FailNow()
+	}
+	if _, err = CombineMaster([]string{shares[0], shares[0], shares[1]}); err != ErrShareMismatch {
+		t.Log("Expected duplicate share to fail")
+		t.FailNow()
+	}
+	other, err := SplitMaster(masterpass, 3, 2)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if _, err = CombineMaster([]string{shares[0], other[1], other[2]}); err != ErrShareMismatch {
+		t.Log("Expected shares from different splits to fail")
+		t.FailNow()
+	}
+
+	// A mistyped share fails its checksum
+	typo := []byte(shares[0])
+	if typo[20] == 'a' {
+		typo[20] = 'b'
+	} else {
+		typo[20] = 'a'
+	}
+	if _, err = CombineMaster([]string{string(typo), shares[1], shares[2]}); err != ErrShareChecksum {
+		t.Log("Expected ErrShareChecksum, got", err)
+		t.FailNow()
+	}
+	if _, err = CombineMaster([]string{"notashare"}); err != ErrShareFormat {
+		t.Log("Expected ErrShareFormat")
+		t.FailNow()
+	}
+
+	for _, p := range [][2]int{{3, 1}, {2, 3}, {256, 3}} {
+		if _, err = SplitMaster(masterpass, p[0], p[1]); err != ErrShareThreshold {
+			t.Logf("Expected ErrShareThreshold for %v of %v", p[1], p[0])
+			t.FailNow()
+		}
+	}
+}
@@ -0,0 +1,882 @@
+package password
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Strength is the estimated resistance of a passphrase to guessing, following the approach of Dropbox's zxcvbn: the
+// passphrase is split into the sequence of dictionary words, keyboard walks, sequences, repeats, dates and brute
+// forced runs that an attacker would need the fewest guesses to find.
+type Strength struct {
+	// Score is 0 (under 10^3 guesses), 1 (under 10^6), 2 (under 10^8), 3 (under 10^10) or 4
+	Score int
+	// Guesses is the estimated number of guesses needed to find the passphrase
+	Guesses float64
+	// Warning explains what makes the passphrase easy to guess, or is empty
+	Warning string
+	// Suggestions are ways to make the passphrase harder to guess, given for scores of 2 or below
+	Suggestions []string
+}
+
+const (
+	// strengthMaxRunes bounds the runes analysed so that long input cannot be used to exhaust the CPU, the rest is
+	// scored as a repeat of the analysed runes
+	strengthMaxRunes = 256
+	// strengthMinGuessesSingle and strengthMinGuessesMulti are the fewest guesses a match inside a longer passphrase
+	// can need, as the attacker must also guess where it is
+	strengthMinGuessesSingle = 10
+	strengthMinGuessesMulti  = 50
+	// strengthSequenceGrowth is added per extra match so a passphrase split into many short matches is not rated
+	// weaker than brute force
+	strengthSequenceGrowth = 10000
+	// strengthKeyboardStarts and strengthKeyboardDegree are the keys and average neighbours of a QWERTY keyboard
+	strengthKeyboardStarts = 94
+	strengthKeyboardDegree = 4.6
+	// strengthMinYearSpace is the fewest years an attacker is assumed to try around the current year
+	strengthMinYearSpace = 20
+)
+
+// Patterns of strengthMatch
+const (
+	patternDictionary = "dictionary"
+	patternSpatial    = "spatial"
+	patternSequence   = "sequence"
+	patternRepeat     = "repeat"
+	patternDate       = "date"
+	patternBruteforce = "bruteforce"
+)
+
+// Ranked lists searched by the dictionary pattern
+const (
+	dictPasswords  = "passwords"
+	dictWords      = "words"
+	dictNames      = "names"
+	dictUserInputs = "user_inputs"
+)
+
+// strengthMatch is a run of runes i to j inclusive of the passphrase matching a pattern
+type strengthMatch struct {
+	pattern string
+	i, j    int
+	token   string
+	guesses float64
+	// dictionary
+	dict     string
+	rank     int
+	reversed bool
+	l33t     bool
+	// spatial
+	turns int
+	// repeat, the length of the repeated block
+	block int
+	// date, true for a year alone
+	year bool
+}
+
+// strengthDict is a ranked list, ranks start at 1 for the most common entry
+type strengthDict struct {
+	name  string
+	ranks map[string]int
+}
+
+// strengthKey is the row and horizontal position in half keys of a key on a QWERTY keyboard
+type strengthKey struct {
+	row, x  int
+	shifted bool
+}
+
+var (
+	strengthOnce     sync.Once
+	strengthDicts    []strengthDict
+	strengthKeyboard map[rune]strengthKey
+	strengthMaxWord  int
+)
+
+// strengthL33t maps common substitutions to the letter they stand for, '1' and '|' stand for either 'i' or 'l'
+var strengthL33t = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '(': 'c', '{': 'c', '[': 'c', '<': 'c', '3': 'e', '6': 'g', '9': 'g',
+	'!': 'i', '0': 'o', '$': 's', '5': 's', '+': 't', '7': 't', '%': 'x', '2': 'z',
+}
+
+// loadStrengthTables builds the ranked lists and keyboard layout on first use
+func loadStrengthTables() {
+	for _, d := range []struct{ name, words string }{
+		{dictPasswords, strengthPasswords},
+		{dictWords, strengthWords},
+		{dictNames, strengthNames},
+	} {
+		ranks := make(map[string]int)
+		for i, w := range strings.Fields(d.words) {
+			if _, ok := ranks[w]; !ok {
+				ranks[w] = i + 1
+			}
+		}
+		strengthDicts = append(strengthDicts, strengthDict{name: d.name, ranks: ranks})
+		for w := range ranks {
+			strengthMaxWord = max(strengthMaxWord, utf8.RuneCountInString(w))
+		}
+	}
+
+	// Rows are staggered by 3, 1 and 1 half keys, so neighbours are 2 apart in a row and 1 apart across rows
+	rows := [][2]string{
+		{"`1234567890-=", "~!@#$%^&*()_+"},
+		{"qwertyuiop[]\\", "QWERTYUIOP{}|"},
+		{"asdfghjkl;'", "ASDFGHJKL:\""},
+		{"zxcvbnm,./", "ZXCVBNM<>?"},
+	}
+	offsets := []int{0, 3, 4, 5}
+	strengthKeyboard = make(map[rune]strengthKey)
+	for r, row := range rows {
+		for s, keys := range row {
+			for c, k := range []rune(keys) {
+				strengthKeyboard[k] = strengthKey{row: r, x: 2*c + offsets[r], shifted: s == 1}
+			}
+		}
+	}
+}
+
+// strengthEstimator holds the user inputs and current year for one EstimateStrength call. maxWord is the length in
+// runes of the longest ranked list entry or user input.
+type strengthEstimator struct {
+	inputs  map[string]int
+	maxWord int
+	year    int
+}
+
+// EstimateStrength takes passphrase as string and optionally user inputs such as the username, email and application
+// name, which are treated as the most common dictionary words, and returns its Strength. Only the first 256 runes
+// are analysed, a longer passphrase is scored as if the analysed runes were repeated to its length.
+func EstimateStrength(userpass string, userInputs ...string) Strength {
+	strengthOnce.Do(loadStrengthTables)
+	e := &strengthEstimator{inputs: make(map[string]int), maxWord: strengthMaxWord, year: time.Now().Year()}
+	for _, in := range userInputs {
+		in = strings.ToLower(in)
+		words := []string{in}
+		if at := strings.LastIndexByte(in, '@'); at > 0 {
+			words = append(words, in[:at])
+		}
+		for _, w := range words {
+			if _, ok := e.inputs[w]; !ok && w != "" {
+				e.inputs[w] = len(e.inputs) + 1
+				e.maxWord = max(e.maxWord, utf8.RuneCountInString(w))
+			}
+		}
+	}
+
+	runes := []rune(userpass)
+	repeats := 1.0
+	if len(runes) > strengthMaxRunes {
+		// As for a repeat match, a repeated block needs the guesses of the block times the repeats
+		repeats = math.Ceil(float64(len(runes)) / strengthMaxRunes)
+		runes = runes[:strengthMaxRunes]
+	}
+	seq, guesses := e.mostGuessable(runes)
+	guesses *= repeats
+
+	s := Strength{Score: strengthScore(guesses), Guesses: guesses}
+	s.Warning, s.Suggestions = strengthFeedback(s.Score, seq)
+	return s
+}
+
+// strengthScore returns the 0 to 4 score of guesses, with a margin so that exactly 10^3 guesses scores 0
+func strengthScore(guesses float64) int {
+	const delta = 5
+	switch {
+	case guesses < 1e3+delta:
+		return 0
+	case guesses < 1e6+delta:
+		return 1
+	case guesses < 1e8+delta:
+		return 2
+	case guesses < 1e10+delta:
+		return 3
+	}
+	return 4
+}
+
+// strengthStep is the best sequence of l matches found ending at a rune, pi is the product of their guesses and g
+// the guesses for the sequence
+type strengthStep struct {
+	m     strengthMatch
+	l     int
+	pi, g float64
+}
+
+// mostGuessable returns the sequence of matches covering runes that needs the fewest guesses, and the guesses. A
+// sequence of l matches needs l! times the product of their guesses, plus strengthSequenceGrowth^(l-1).
+func (e *strengthEstimator) mostGuessable(runes []rune) ([]strengthMatch, float64) {
+	n := len(runes)
+	if n == 0 {
+		return nil, 1
+	}
+	byEnd := make([][]strengthMatch, n)
+	for _, m := range e.matches(runes) {
+		m.guesses = max(m.guesses, minGuesses(m.j-m.i+1, n))
+		byEnd[m.j] = append(byEnd[m.j], m)
+	}
+
+	// opt[k] holds the best sequences covering runes 0 to k, at most one per number of matches. Few numbers of
+	// matches are ever kept, so the lists are short.
+	opt := make([][]strengthStep, n)
+	update := func(m strengthMatch, l int, prevPi float64) {
+		pi := m.guesses * prevPi
+		g := factorial(l)*pi + math.Pow(strengthSequenceGrowth, float64(l-1))
+		// A sequence is only kept if no sequence with as many or fewer matches needs as few guesses
+		for _, c := range opt[m.j] {
+			if c.l <= l && c.g <= g {
+				return
+			}
+		}
+		step := strengthStep{m: m, l: l, pi: pi, g: g}
+		for k, c := range opt[m.j] {
+			if c.l == l {
+				opt[m.j][k] = step
+				return
+			}
+		}
+		opt[m.j] = append(opt[m.j], step)
+	}
+	bruteforce := func(i, j int) strengthMatch {
+		length := j - i + 1
+		guesses := max(math.Pow(10, float64(length)), strengthMinGuessesMulti+1)
+		if length == 1 {
+			guesses = strengthMinGuessesSingle + 1
+		}
+		return strengthMatch{pattern: patternBruteforce, i: i, j: j, guesses: guesses}
+	}
+
+	for k := 0; k < n; k++ {
+		for _, m := range byEnd[k] {
+			if m.i == 0 {
+				update(m, 1, 1)
+				continue
+			}
+			for _, prev := range opt[m.i-1] {
+				update(m, prev.l+1, prev.pi)
+			}
+		}
+		update(bruteforce(0, k), 1, 1)
+		for i := 1; i <= k; i++ {
+			// Adjacent brute forced runs are never better than one longer run
+			for _, prev := range opt[i-1] {
+				if prev.m.pattern != patternBruteforce {
+					update(bruteforce(i, k), prev.l+1, prev.pi)
+				}
+			}
+		}
+	}
+
+	best := opt[n-1][0]
+	for _, c := range opt[n-1][1:] {
+		if c.g < best.g || (c.g == best.g && c.l < best.l) {
+			best = c
+		}
+	}
+	seq := make([]strengthMatch, best.l)
+	for step := best; ; {
+		seq[step.l-1] = step.m
+		if step.l == 1 {
+			break
+		}
+		for _, c := range opt[step.m.i-1] {
+			if c.l == step.l-1 {
+				step = c
+				break
+			}
+		}
+	}
+	return seq, best.g
+}
+
+// minGuesses returns the fewest guesses a match of length runes in a passphrase of n runes needs
+func minGuesses(length, n int) float64 {
+	switch {
+	case length == n:
+		return 1
+	case length == 1:
+		return strengthMinGuessesSingle
+	}
+	return strengthMinGuessesMulti
+}
+
+// matches returns every dictionary, spatial, sequence, repeat and date match in runes
+func (e *strengthEstimator) matches(runes []rune) []strengthMatch {
+	var found []strengthMatch
+	found = append(found, e.dictionaryMatches(runes)...)
+	found = append(found, e.reversedMatches(runes)...)
+	found = append(found, e.l33tMatches(runes)...)
+	found = append(found, spatialMatches(runes)...)
+	found = append(found, sequenceMatches(runes)...)
+	found = append(found, e.repeatMatches(runes)...)
+	found = append(found, e.dateMatches(runes)...)
+	return found
+}
+
+// lookup returns a match without guesses for every run of at least 3 runes of lower found in a ranked list
+func (e *strengthEstimator) lookup(lower []rune) []strengthMatch {
+	var found []strengthMatch
+	for i := range lower {
+		for j := i + 2; j < len(lower) && j-i < e.maxWord; j++ {
+			word := string(lower[i : j+1])
+			for _, d := range strengthDicts {
+				if rank, ok := d.ranks[word]; ok {
+					found = append(found, strengthMatch{pattern: patternDictionary, i: i, j: j, dict: d.name, rank: rank})
+				}
+			}
+			if rank, ok := e.inputs[word]; ok {
+				found = append(found, strengthMatch{pattern: patternDictionary, i: i, j: j, dict: dictUserInputs, rank: rank})
+			}
+		}
+	}
+	return found
+}
+
+// dictionaryMatches returns the ranked list entries in runes, ignoring case
+func (e *strengthEstimator) dictionaryMatches(runes []rune) []strengthMatch {
+	found := e.lookup(lowerRunes(runes))
+	for k := range found {
+		m := &found[k]
+		m.token = string(runes[m.i : m.j+1])
+		m.guesses = float64(m.rank) * uppercaseVariations(m.token)
+	}
+	return found
+}
+
+// reversedMatches returns the ranked list entries in runes written backwards, which need twice the guesses
+func (e *strengthEstimator) reversedMatches(runes []rune) []strengthMatch {
+	lower := lowerRunes(runes)
+	n := len(lower)
+	for i := 0; i < n/2; i++ {
+		lower[i], lower[n-1-i] = lower[n-1-i], lower[i]
+	}
+	found := e.lookup(lower)
+	for k := range found {
+		m := &found[k]
+		m.i, m.j = n-1-m.j, n-1-m.i
+		m.token = string(runes[m.i : m.j+1])
+		m.guesses = float64(m.rank) * uppercaseVariations(m.token) * 2
+		m.reversed = true
+	}
+	return found
+}
+
+// l33tMatches returns the ranked list entries in runes with letters replaced by look-alike digits and symbols
+func (e *strengthEstimator) l33tMatches(runes []rune) []strengthMatch {
+	lower := lowerRunes(runes)
+	var found []strengthMatch
+	for _, ambiguous := range []rune{'i', 'l'} {
+		translated := make([]rune, len(lower))
+		changed := false
+		for k, r := range lower {
+			translated[k] = r
+			if letter, ok := strengthL33t[r]; ok {
+				translated[k] = letter
+			} else if r == '1' || r == '|' {
+				translated[k] = ambiguous
+			}
+			changed = changed || translated[k] != r
+		}
+		if !changed {
+			continue
+		}
+		for _, m := range e.lookup(translated) {
+			// The substitutions used by this match, in order of first use
+			var subs [][2]rune
+			for k := m.i; k <= m.j; k++ {
+				sub := [2]rune{lower[k], translated[k]}
+				if sub[0] == sub[1] {
+					continue
+				}
+				seen := false
+				for _, s := range subs {
+					seen = seen || s == sub
+				}
+				if !seen {
+					subs = append(subs, sub)
+				}
+			}
+			// Entries without a substitution are found by dictionaryMatches, ambiguous ones by both passes
+			if len(subs) == 0 || (ambiguous == 'l' && !strings.ContainsAny(string(lower[m.i:m.j+1]), "1|")) {
+				continue
+			}
+			m.token = string(runes[m.i : m.j+1])
+			m.guesses = float64(m.rank) * uppercaseVariations(m.token) * l33tVariations(lower[m.i:m.j+1], subs)
+			m.l33t = true
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+// uppercaseVariations returns the number of ways token's letters could be capitalised as it is: 1 if it has no
+// upper case letters, 2 if only the first or last letter or every letter is upper case, or the number of ways to
+// choose as many upper or lower case letters
+func uppercaseVariations(token string) float64 {
+	runes := []rune(token)
+	upper, lower := 0, 0
+	for _, r := range runes {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+	switch {
+	case upper == 0:
+		return 1
+	case lower == 0,
+		upper == 1 && unicode.IsUpper(runes[0]),
+		upper == 1 && unicode.IsUpper(runes[len(runes)-1]):
+		return 2
+	}
+	variations := 0.0
+	for k := 1; k <= min(upper, lower); k++ {
+		variations += binomial(upper+lower, k)
+	}
+	return variations
+}
+
+// l33tVariations returns the number of ways the substitutions subs could be applied to the lower cased token: 2 if
+// every instance of the letter was substituted, or the number of ways to choose as many substituted or unsubstituted
+// instances
+func l33tVariations(token []rune, subs [][2]rune) float64 {
+	variations := 1.0
+	for _, sub := range subs {
+		substituted, unsubstituted := 0, 0
+		for _, r := range token {
+			switch r {
+			case sub[0]:
+				substituted++
+			case sub[1]:
+				unsubstituted++
+			}
+		}
+		if unsubstituted == 0 {
+			variations *= 2
+			continue
+		}
+		possibilities := 0.0
+		for k := 1; k <= min(substituted, unsubstituted); k++ {
+			possibilities += binomial(substituted+unsubstituted, k)
+		}
+		variations *= possibilities
+	}
+	return variations
+}
+
+// spatialMatches returns the walks of at least 3 neighbouring keys on a QWERTY keyboard in runes
+func spatialMatches(runes []rune) []strengthMatch {
+	var found []strengthMatch
+	for i := 0; i < len(runes)-2; {
+		cur, ok := strengthKeyboard[runes[i]]
+		if !ok {
+			i++
+			continue
+		}
+		j, turns, shifted := i, 0, 0
+		if cur.shifted {
+			shifted++
+		}
+		var dir [2]int
+		for j+1 < len(runes) {
+			next, ok := strengthKeyboard[runes[j+1]]
+			if !ok || !adjacentKeys(cur, next) {
+				break
+			}
+			if d := [2]int{next.row - cur.row, next.x - cur.x}; j == i || d != dir {
+				turns++
+				dir = d
+			}
+			if next.shifted {
+				shifted++
+			}
+			cur = next
+			j++
+		}
+		if j-i < 2 {
+			i++
+			continue
+		}
+		found = append(found, strengthMatch{
+			pattern: patternSpatial,
+			i:       i,
+			j:       j,
+			token:   string(runes[i : j+1]),
+			guesses: spatialGuesses(j-i+1, turns, shifted),
+			turns:   turns,
+		})
+		i = j + 1
+	}
+	return found
+}
+
+// adjacentKeys reports whether a and b are neighbours, ignoring shift
+func adjacentKeys(a, b strengthKey) bool {
+	dx := b.x - a.x
+	switch b.row - a.row {
+	case 0:
+		return dx == 2 || dx == -2
+	case 1, -1:
+		return dx == 1 || dx == -1
+	}
+	return false
+}
+
+// spatialGuesses returns the guesses for a walk of length keys with turns changes of direction, shifted of which
+// were typed with shift
+func spatialGuesses(length, turns, shifted int) float64 {
+	guesses := 0.0
+	for i := 2; i <= length; i++ {
+		for j := 1; j <= min(turns, i-1); j++ {
+			guesses += binomial(i-1, j-1) * strengthKeyboardStarts * math.Pow(strengthKeyboardDegree, float64(j))
+		}
+	}
+	if shifted > 0 {
+		unshifted := length - shifted
+		if unshifted == 0 {
+			return guesses * 2
+		}
+		variations := 0.0
+		for k := 1; k <= min(shifted, unshifted); k++ {
+			variations += binomial(length, k)
+		}
+		guesses *= variations
+	}
+	return guesses
+}
+
+// sequenceMatches returns the runs of at least 3 runes with a constant step of up to 5 code points in runes, ex.
+// "abc", "7531" or "ZYX"
+func sequenceMatches(runes []rune) []strengthMatch {
+	var found []strengthMatch
+	for i := 0; i < len(runes)-2; {
+		delta := runes[i+1] - runes[i]
+		j := i + 1
+		for j+1 < len(runes) && runes[j+1]-runes[j] == delta {
+			j++
+		}
+		if delta != 0 && delta >= -5 && delta <= 5 && j-i >= 2 {
+			base := 26.0
+			switch first := runes[i]; {
+			case strings.ContainsRune("aAzZ019", first):
+				base = 4
+			case first >= '0' && first <= '9':
+				base = 10
+			}
+			if delta < 0 {
+				base *= 2
+			}
+			found = append(found, strengthMatch{
+				pattern: patternSequence,
+				i:       i,
+				j:       j,
+				token:   string(runes[i : j+1]),
+				guesses: base * float64(j-i+1),
+			})
+		}
+		i = j
+	}
+	return found
+}
+
+// repeatMatches returns the runs of a block of runes repeated at least twice in runes, ex. "aaa" or "abcabc", which
+// need the guesses of the block times the repeats
+func (e *strengthEstimator) repeatMatches(runes []rune) []strengthMatch {
+	var found []strengthMatch
+	for i := 0; i < len(runes)-1; {
+		block, count := 0, 0
+		for b := 1; i+2*b <= len(runes); b++ {
+			k := 1
+			for i+(k+1)*b <= len(runes) && equalRunes(runes[i+k*b:i+(k+1)*b], runes[i:i+b]) {
+				k++
+			}
+			if k >= 2 && b*k > block*count {
+				block, count = b, k
+			}
+		}
+		if count == 0 {
+			i++
+			continue
+		}
+		_, guesses := e.mostGuessable(runes[i : i+block])
+		j := i + block*count - 1
+		found = append(found, strengthMatch{
+			pattern: patternRepeat,
+			i:       i,
+			j:       j,
+			token:   string(runes[i : j+1]),
+			guesses: guesses * float64(count),
+			block:   block,
+		})
+		i = j + 1
+	}
+	return found
+}
+
+// strengthDateSeparators may separate the day, month and year of a date
+const strengthDateSeparators = " -/\\_."
+
+// dateMatches returns the years from 1900 to 2050 and the dates with or without separators in runes, ex. "1987",
+// "13121987", "2024-03-01" or "1.3.24"
+func (e *strengthEstimator) dateMatches(runes []rune) []strengthMatch {
+	var found []strengthMatch
+	n := len(runes)
+	for i := 0; i+4 <= n; i++ {
+		if y, ok := digitsValue(runes[i : i+4]); ok && y >= 1900 && y <= 2050 {
+			found = append(found, strengthMatch{pattern: patternDate, i: i, j: i + 3, token: string(runes[i : i+4]), guesses: e.yearSpace(y), year: true})
+		}
+	}
+	for i := 0; i < n; i++ {
+		for l := 4; l <= 8 && i+l <= n; l++ {
+			if _, ok := digitsValue(runes[i : i+l]); !ok {
+				break
+			}
+			if y, ok := e.splitDate(runes[i : i+l]); ok {
+				found = append(found, strengthMatch{pattern: patternDate, i: i, j: i + l - 1, token: string(runes[i : i+l]), guesses: 365 * e.yearSpace(y)})
+			}
+		}
+		for l := 6; l <= 10 && i+l <= n; l++ {
+			if y, ok := e.separatedDate(runes[i : i+l]); ok {
+				found = append(found, strengthMatch{pattern: patternDate, i: i, j: i + l - 1, token: string(runes[i : i+l]), guesses: 4 * 365 * e.yearSpace(y)})
+			}
+		}
+	}
+	return found
+}
+
+// yearSpace returns the years an attacker would try to reach year from the current year
+func (e *strengthEstimator) yearSpace(year int) float64 {
+	d := year - e.year
+	if d < 0 {
+		d = -d
+	}
+	return float64(max(d, strengthMinYearSpace))
+}
+
+// splitDate returns the year of the day, month and year closest to the current year that the digits in token can be
+// split into, and whether there is one
+func (e *strengthEstimator) splitDate(token []rune) (int, bool) {
+	best, found := 0, false
+	for p1 := 1; p1 < len(token)-1; p1++ {
+		for p2 := p1 + 1; p2 < len(token); p2++ {
+			y, ok := dayMonthYear(token[:p1], token[p1:p2], token[p2:])
+			if ok && (!found || e.yearSpace(y) < e.yearSpace(best)) {
+				best, found = y, true
+			}
+		}
+	}
+	return best, found
+}
+
+// separatedDate returns the year of token if it is a day, month and year separated twice by the same separator
+func (e *strengthEstimator) separatedDate(token []rune) (int, bool) {
+	sep := rune(-1)
+	for _, r := range token {
+		if r < '0' || r > '9' {
+			sep = r
+			break
+		}
+	}
+	if !strings.ContainsRune(strengthDateSeparators, sep) {
+		return 0, false
+	}
+	parts := strings.Split(string(token), string(sep))
+	if len(parts) != 3 {
+		return 0, false
+	}
+	return dayMonthYear([]rune(parts[0]), []rune(parts[1]), []rune(parts[2]))
+}
+
+// dayMonthYear returns the year if a, b and c are a year followed by a day and month in either order, or a day and
+// month in either order followed by a year
+func dayMonthYear(a, b, c []rune) (int, bool) {
+	if y, ok := dateYear(a); ok && dayMonth(b, c) {
+		return y, true
+	}
+	if y, ok := dateYear(c); ok && dayMonth(a, b) {
+		return y, true
+	}
+	return 0, false
+}
+
+// dateYear returns the year written as 4 digits from 1000 to 2050, or as 2 digits taken as 1951 to 2050
+func dateYear(part []rune) (int, bool) {
+	v, ok := digitsValue(part)
+	switch {
+	case !ok:
+		return 0, false
+	case len(part) == 4:
+		return v, v >= 1000 && v <= 2050
+	case len(part) == 2 && v > 50:
+		return 1900 + v, true
+	case len(part) == 2:
+		return 2000 + v, true
+	}
+	return 0, false
+}
+
+// dayMonth reports whether a and b are a day and month in either order
+func dayMonth(a, b []rune) bool {
+	if len(a) > 2 || len(b) > 2 {
+		return false
+	}
+	x, okx := digitsValue(a)
+	y, oky := digitsValue(b)
+	if !okx || !oky {
+		return false
+	}
+	return (x >= 1 && x <= 31 && y >= 1 && y <= 12) || (x >= 1 && x <= 12 && y >= 1 && y <= 31)
+}
+
+// digitsValue returns the value of runes if they are all ASCII digits
+func digitsValue(runes []rune) (int, bool) {
+	if len(runes) == 0 {
+		return 0, false
+	}
+	v := 0
+	for _, r := range runes {
+		if r < '0' || r > '9' {
+			return 0, false
+		}
+		v = v*10 + int(r-'0')
+	}
+	return v, true
+}
+
+// strengthFeedback returns the warning and suggestions for a passphrase with score split into seq
+func strengthFeedback(score int, seq []strengthMatch) (string, []string) {
+	if len(seq) == 0 {
+		return "", []string{"Use a few words, avoid common phrases", "No need for symbols, digits, or uppercase letters"}
+	}
+	if score > 2 {
+		return "", nil
+	}
+	longest := seq[0]
+	for _, m := range seq[1:] {
+		if m.j-m.i > longest.j-longest.i {
+			longest = m
+		}
+	}
+	warning, suggestions := matchFeedback(longest, len(seq) == 1)
+	return warning, append([]string{"Add another word or two, uncommon words are better"}, suggestions...)
+}
+
+// matchFeedback returns the warning and suggestions for match m, sole if it is the whole passphrase
+func matchFeedback(m strengthMatch, sole bool) (string, []string) {
+	switch m.pattern {
+	case patternDictionary:
+		return dictionaryFeedback(m, sole)
+	case patternSpatial:
+		if m.turns == 1 {
+			return "Straight rows of keys are easy to guess", []string{"Use a longer keyboard pattern with more turns"}
+		}
+		return "Short keyboard patterns are easy to guess", []string{"Use a longer keyboard pattern with more turns"}
+	case patternRepeat:
+		if m.block == 1 {
+			return `Repeats like "aaa" are easy to guess`, []string{"Avoid repeated words and characters"}
+		}
+		return `Repeats like "abcabcabc" are only slightly harder to guess than "abc"`, []string{"Avoid repeated words and characters"}
+	case patternSequence:
+		return "Sequences like abc or 6543 are easy to guess", []string{"Avoid sequences"}
+	case patternDate:
+		if m.year {
+			return "Recent years are easy to guess", []string{"Avoid recent years", "Avoid years that are associated with you"}
+		}
+		return "Dates are often easy to guess", []string{"Avoid dates and years that are associated with you"}
+	}
+	return "", nil
+}
+
+// dictionaryFeedback returns the warning and suggestions for dictionary match m
+func dictionaryFeedback(m strengthMatch, sole bool) (string, []string) {
+	var warning string
+	switch m.dict {
+	case dictPasswords:
+		switch {
+		case sole && !m.l33t && !m.reversed && m.rank <= 10:
+			warning = "This is a top-10 common password"
+		case sole && !m.l33t && !m.reversed && m.rank <= 100:
+			warning = "This is a top-100 common password"
+		case sole && !m.l33t && !m.reversed:
+			warning = "This is a very common password"
+		case m.guesses <= 1e4:
+			warning = "This is similar to a commonly used password"
+		}
+	case dictWords:
+		if sole {
+			warning = "A word by itself is easy to guess"
+		}
+	case dictNames:
+		warning = "Common names and surnames are easy to guess"
+		if sole {
+			warning = "Names and surnames by themselves are easy to guess"
+		}
+	case dictUserInputs:
+		warning = "Passphrases containing your name or email are easy to guess"
+	}
+
+	var suggestions []string
+	runes := []rune(m.token)
+	switch {
+	case unicode.IsUpper(runes[0]) && uppercaseVariations(string(runes[1:])) == 1:
+		suggestions = append(suggestions, "Capitalization doesn't help very much")
+	case strings.ToUpper(m.token) == m.token && strings.ToLower(m.token) != m.token:
+		suggestions = append(suggestions, "All-uppercase is almost as easy to guess as all-lowercase")
+	}
+	if m.reversed && len(runes) >= 4 {
+		suggestions = append(suggestions, "Reversed words aren't much harder to guess")
+	}
+	if m.l33t {
+		suggestions = append(suggestions, "Predictable substitutions like '@' instead of 'a' don't help very much")
+	}
+	return warning, suggestions
+}
+
+// equalRunes reports whether a and b hold the same runes
+func equalRunes(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// lowerRunes returns a lower cased copy of runes
+func lowerRunes(runes []rune) []rune {
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	return lower
+}
+
+// binomial returns n choose k
+func binomial(n, k int) float64 {
+	if k < 0 || k > n {
+		return 0
+	}
+	r := 1.0
+	for d := 1; d <= k; d++ {
+		r = r * float64(n-k+d) / float64(d)
+	}
+	return r
+}
+
+// factorial returns n!
+func factorial(n int) float64 {
+	r := 1.0
+	for i := 2; i <= n; i++ {
+		r *= float64(i)
+	}
+	return r
+}
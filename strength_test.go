@@ -0,0 +1,119 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEstimateStrength(t *testing.T) {
+	for _, c := range []struct {
+		userpass string
+		maxScore int
+		warning  string
+	}{
+		{"password", 0, "This is a top-10 common password"},
+		{"qwerty123", 1, ""},
+		{"drowssap", 0, "This is similar to a commonly used password"},
+		{"P@ssw0rd", 0, "This is similar to a commonly used password"},
+		{"aaaaaaaa", 0, `Repeats like "aaa" are easy to guess`},
+		{"abcabcabc", 0, `Repeats like "abcabcabc" are only slightly harder to guess than "abc"`},
+		{"zxcvfdsa", 1, "Short keyboard patterns are easy to guess"},
+		{"13/12/1987", 1, "Dates are often easy to guess"},
+		{"jdoe1987", 1, "Passphrases containing your name or email are easy to guess"},
+	} {
+		s := EstimateStrength(c.userpass, "jdoe@example.com")
+		if s.Score > c.maxScore {
+			t.Logf("%q scored %v (%v guesses), expected at most %v", c.userpass, s.Score, s.Guesses, c.maxScore)
+			t.FailNow()
+		}
+		if c.warning != "" && s.Warning != c.warning {
+			t.Logf("%q: expected warning %q, got %q", c.userpass, c.warning, s.Warning)
+			t.FailNow()
+		}
+		if len(s.Suggestions) == 0 {
+			t.Logf("%q: expected suggestions", c.userpass)
+			t.FailNow()
+		}
+	}
+
+	s := EstimateStrength("Password2024!")
+	if s.Score > 2 || !strings.Contains(strings.Join(s.Suggestions, "\n"), "Capitalization") {
+		t.Logf("Unexpected estimate for Password2024!: %+v", s)
+		t.FailNow()
+	}
+
+	for _, userpass := range []string{"vT9#qLmz2!xRw8$kPd", "kx7wolfgang!crane"} {
+		s := EstimateStrength(userpass)
+		if s.Score != 4 || s.Warning != "" || len(s.Suggestions) != 0 {
+			t.Logf("%q: expected score 4 without feedback, got %+v", userpass, s)
+			t.FailNow()
+		}
+	}
+
+	// A repeated block is barely harder than the block alone
+	if EstimateStrength("correcthorse").Guesses*10 < EstimateStrength("correcthorsecorrecthorse").Guesses {
+		t.Log("Expected repeat to need few more guesses than its block")
+		t.FailNow()
+	}
+	// Long repeated passphrases, including those longer than the runes analysed
+	for _, userpass := range []string{
+		strings.Repeat("a", 70),
+		strings.Repeat("password", 9),
+		strings.Repeat("12", 40),
+		strings.Repeat("password", 100),
+		strings.Repeat("a", 10000),
+	} {
+		if s := EstimateStrength(userpass); s.Score > 1 {
+			t.Logf("%v runes of repeats scored %v (%v guesses), expected at most 1", len(userpass), s.Score, s.Guesses)
+			t.FailNow()
+		}
+	}
+	if (&Policy{MinScore: 3}).Check(strings.Repeat("a", 70)) == nil {
+		t.Log("Expected long repeat to fail MinScore")
+		t.FailNow()
+	}
+	if s := EstimateStrength(strings.Repeat("vT9#qLmz2!xRw8$kPd", 20)); s.Score != 4 {
+		t.Logf("Expected long random passphrase to score 4, got %v", s.Score)
+		t.FailNow()
+	}
+
+	if s := EstimateStrength(""); s.Score != 0 || len(s.Suggestions) == 0 {
+		t.Logf("Unexpected estimate for empty passphrase: %+v", s)
+		t.FailNow()
+	}
+}
+
+func TestPolicyMinScore(t *testing.T) {
+	h, err := NewHasher("masterpassphrase", 0, DefaultParams)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	h.SetPolicy(&Policy{MinLength: 8, MinScore: 3})
+	_, err = h.Hash("Password2024!", testArgon2Params)
+	var perr *PolicyError
+	if !errors.As(err, &perr) || len(perr.Violations) != 1 || perr.Violations[0].Rule != RuleStrength {
+		t.Logf("Expected strength violation, got %v", err)
+		t.FailNow()
+	}
+	if v := perr.Violations[0]; v.Limit != 3 || v.Strength == nil || v.Strength.Warning == "" {
+		t.Logf("Unexpected violation: %+v", v)
+		t.FailNow()
+	}
+	if _, err = h.Hash("kx7wolfgang!crane", testArgon2Params); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	// Identities given to Check are user inputs
+	p := &Policy{MinScore: 3}
+	if p.Check("jdoe@example.com2024") != nil {
+		t.Log("Expected passphrase to pass without identities")
+		t.FailNow()
+	}
+	if !errors.Is(p.Check("jdoe@example.com2024", "jdoe@example.com"), ErrPolicyViolation) {
+		t.Log("Expected identity to lower the score")
+		t.FailNow()
+	}
+}
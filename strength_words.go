@@ -0,0 +1,65 @@
+package password
+
+// Ranked frequency lists used by EstimateStrength, most common first. They are kept short so the package stays small,
+// a passphrase made of words missing from them is still penalised by the keyboard, sequence, repeat and date patterns.
+
+// strengthPasswords are common passwords from public breach corpora
+const strengthPasswords = `123456 password 12345678 qwerty 123456789 12345 1234 111111 1234567 dragon
+123123 baseball abc123 football monkey letmein 696969 shadow master 666666
+qwertyuiop 123321 mustang 1234567890 michael 654321 superman 1qaz2wsx 7777777 121212
+000000 qazwsx 123qwe killer trustno1 jordan jennifer zxcvbnm asdfgh hunter
+buster soccer harley batman andrew tigger sunshine iloveyou 2000 charlie
+robert thomas hockey ranger daniel starwars klaster 112233 george computer
+michelle jessica pepper 1111 zxcvbn 555555 11111111 131313 freedom 777777
+pass maggie 159753 aaaaaa ginger princess joshua cheese amanda summer
+love ashley nicole chelsea biteme matthew access yankees 987654321 dallas
+austin thunder taylor matrix william corvette hello martin heather secret
+merlin diamond 1234qwer gfhjkm hammer silver 222222 88888888 anthony justin
+test bailey q1w2e3r4t5 patrick internet scooter orange 11111 golfer cookie
+richard samantha bigdog guitar jackson whatever mickey chicken sparky snoopy
+maverick phoenix camaro peanut morgan welcome falcon cowboy ferrari samsung
+andrea smokey steelers joseph mercedes dakota arsenal eagles melissa boomer
+booboo spider nascar monster tigers yellow xxxxxx 123123123 gateway marina
+diablo bulldog qwer1234 compaq purple banana junior hannah 123654
+porsche lakers iceman money cowboys 987654 london tennis 999999 ncc1701
+coffee scooby 0000 miller boston q1w2e3r4 brandon yamaha chester mother
+forever johnny edward 333333 oliver redsox player nikita knight fender
+barney midnight please brandy chicago badboy slayer rangers charles angel
+flower bigdaddy rabbit wizard jasper enter rachel chris steven winner adidas
+victoria natasha 1q2w3e4r jasmine winter prince marine ghbdtn fishing
+cocacola casper james 232323 raiders 888888 marlboro gandalf asdfasdf crystal
+87654321 12344321 golf heaven 112211 admin passw0rd password1 password123
+qwerty123 iloveyou1 abc12345 welcome1 letmein1 monkey1 dragon1 sunshine1
+princess1 football1 baseball1 trustno1 changeme default root toor login`
+
+// strengthWords are common English words
+const strengthWords = `you the and that have for not with this but his from they say her she will one all would
+there their what out about who get which when make can like time just him know take people into year your
+good some could them see other than then now look only come its over think also back after use two how our
+work first well way even new want because any these give day most us man find here thing many tell very
+child world life hand part place case week company system program question government number night point home
+water room mother area money story fact month lot right study book eye job word business issue side kind head
+house service friend father power hour game line end member law car city community name president team minute
+idea kid body information school face others level office door health person art war history party result
+change morning reason research girl guy moment air teacher force education love dog cat baby sun moon star
+summer winter spring autumn flower tree river ocean beach mountain island forest garden apple orange banana
+cherry lemon chocolate coffee dragon tiger lion eagle horse monkey rabbit turtle shadow silver golden purple
+yellow green blue black white brown pink red happy lucky magic secret freedom heaven angel master hunter
+soccer hockey tennis football baseball basketball music guitar piano rock heart dream sweet honey sugar candy
+cookie pepper cheese butter bread pizza chicken diamond crystal thunder storm rain snow fire ice stone steel
+iron gold metal wolf bear fox snake spider killer knight king queen prince princess castle sword shield
+battle warrior soldier pirate ninja wizard monster ghost demon zombie robot rocket space planet earth galaxy
+universe computer internet phone camera window mirror picture letter paper pencil table chair bottle glass
+correct staple battery horse hello welcome sunshine rainbow butterfly kitten puppy family children friends
+forever always never nothing something everything everyone someone school college summer holiday birthday
+christmas easter party travel journey adventure freedom liberty justice peace victory champion winner
+purple orange violet scarlet crimson blossom cloud sky wind light dark night day morning evening`
+
+// strengthNames are common first names and surnames
+const strengthNames = `james john robert michael william david richard joseph thomas charles christopher daniel
+matthew anthony mark donald steven paul andrew joshua kevin brian george edward ronald timothy jason jeffrey
+ryan jacob gary nicholas eric jonathan stephen larry justin scott brandon benjamin samuel frank gregory
+mary patricia jennifer linda elizabeth barbara susan jessica sarah karen nancy lisa betty margaret sandra
+ashley kimberly emily donna michelle dorothy carol amanda melissa deborah stephanie rebecca sharon laura
+smith johnson williams brown jones garcia miller davis rodriguez martinez hernandez lopez gonzalez wilson
+anderson taylor moore jackson martin lee thompson white harris clark lewis robinson walker young allen`